@@ -0,0 +1,304 @@
+// Package ast declares the syntax tree of nano-go source files. It is built
+// from the ANTLR parse tree by parser.ParseFile and is what the later passes
+// work on. The node set mirrors go/ast, operators are go/token tokens.
+package ast
+
+import (
+	"go/token"
+
+	"nano-go/diag"
+)
+
+// Node is implemented by every node of the tree.
+type Node interface {
+	Pos() diag.Position
+}
+
+type Expr interface {
+	Node
+	exprNode()
+}
+
+type Stmt interface {
+	Node
+	stmtNode()
+}
+
+type Decl interface {
+	Node
+	declNode()
+}
+
+type Spec interface {
+	Node
+	specNode()
+}
+
+// ----------------------------------------------------------------------------
+// Expressions
+
+type (
+	// BadExpr is a placeholder for an expression that could not be built.
+	BadExpr struct {
+		From diag.Position
+	}
+
+	Ident struct {
+		NamePos diag.Position
+		Name    string
+	}
+
+	// BasicLit is an integer, floating-point, imaginary, rune or string
+	// literal. Value holds the literal as written in the source.
+	BasicLit struct {
+		ValuePos diag.Position
+		Kind     token.Token // token.INT, FLOAT, IMAG, CHAR or STRING
+		Value    string
+	}
+
+	// CompositeLit is T{...}. Type is nil for the elided type of nested
+	// literals, Elts may contain KeyValueExprs.
+	CompositeLit struct {
+		Type   Expr
+		Lbrace diag.Position
+		Elts   []Expr
+		Rbrace diag.Position
+	}
+
+	FuncLit struct {
+		Type *FuncType
+		Body *BlockStmt
+	}
+
+	ParenExpr struct {
+		Lparen diag.Position
+		X      Expr
+	}
+
+	SelectorExpr struct {
+		X   Expr
+		Sel *Ident
+	}
+
+	IndexExpr struct {
+		X      Expr
+		Lbrack diag.Position
+		Index  Expr
+	}
+
+	// SliceExpr is X[Low:High] or X[Low:High:Max], any of the indices but
+	// Max of a full slice expression may be nil.
+	SliceExpr struct {
+		X      Expr
+		Lbrack diag.Position
+		Low    Expr
+		High   Expr
+		Max    Expr
+		Slice3 bool
+	}
+
+	TypeAssertExpr struct {
+		X    Expr
+		Type Expr
+	}
+
+	// CallExpr is a function call or a conversion. Ellipsis is set for
+	// f(xs...).
+	CallExpr struct {
+		Fun      Expr
+		Lparen   diag.Position
+		Args     []Expr
+		Ellipsis bool
+	}
+
+	// StarExpr is *X, a pointer type or an indirection.
+	StarExpr struct {
+		Star diag.Position
+		X    Expr
+	}
+
+	UnaryExpr struct {
+		OpPos diag.Position
+		Op    token.Token
+		X     Expr
+	}
+
+	BinaryExpr struct {
+		X     Expr
+		OpPos diag.Position
+		Op    token.Token
+		Y     Expr
+	}
+
+	// KeyValueExpr is a keyed element of a composite literal.
+	KeyValueExpr struct {
+		Key   Expr
+		Colon diag.Position
+		Value Expr
+	}
+)
+
+// ----------------------------------------------------------------------------
+// Types
+
+type (
+	// ArrayType is [Len]Elt, []Elt when Len is nil and [...]Elt when Len is
+	// an *Ellipsis.
+	ArrayType struct {
+		Lbrack diag.Position
+		Len    Expr
+		Elt    Expr
+	}
+
+	// Ellipsis is the ... of a variadic parameter type, Elt is its element
+	// type, or of an array length, Elt is nil then.
+	Ellipsis struct {
+		Ellipsis diag.Position
+		Elt      Expr
+	}
+
+	StructType struct {
+		Struct diag.Position
+		Fields *FieldList
+	}
+
+	FuncType struct {
+		Func    diag.Position
+		Params  *FieldList
+		Results *FieldList
+	}
+
+	InterfaceType struct {
+		Interface diag.Position
+		Methods   *FieldList
+	}
+
+	MapType struct {
+		Map   diag.Position
+		Key   Expr
+		Value Expr
+	}
+
+	ChanType struct {
+		Begin diag.Position
+		Dir   ChanDir
+		Value Expr
+	}
+)
+
+type ChanDir int
+
+const (
+	SEND ChanDir = 1 << iota
+	RECV
+)
+
+// Field is a parameter, a result, a struct field or an interface method.
+// Names is empty for unnamed parameters and embedded fields.
+type Field struct {
+	Names []*Ident
+	Type  Expr
+	Tag   *BasicLit
+}
+
+type FieldList struct {
+	Opening diag.Position
+	List    []*Field
+}
+
+// NumFields counts the fields of the list, a field with several names
+// counts once per name.
+func (f *FieldList) NumFields() int {
+	if f == nil {
+		return 0
+	}
+
+	n := 0
+	for _, field := range f.List {
+		if len(field.Names) == 0 {
+			n++
+		} else {
+			n += len(field.Names)
+		}
+	}
+
+	return n
+}
+
+func (x *BadExpr) Pos() diag.Position        { return x.From }
+func (x *Ident) Pos() diag.Position          { return x.NamePos }
+func (x *BasicLit) Pos() diag.Position       { return x.ValuePos }
+func (x *FuncLit) Pos() diag.Position        { return x.Type.Pos() }
+func (x *ParenExpr) Pos() diag.Position      { return x.Lparen }
+func (x *SelectorExpr) Pos() diag.Position   { return x.X.Pos() }
+func (x *IndexExpr) Pos() diag.Position      { return x.X.Pos() }
+func (x *SliceExpr) Pos() diag.Position      { return x.X.Pos() }
+func (x *TypeAssertExpr) Pos() diag.Position { return x.X.Pos() }
+func (x *CallExpr) Pos() diag.Position       { return x.Fun.Pos() }
+func (x *StarExpr) Pos() diag.Position       { return x.Star }
+func (x *UnaryExpr) Pos() diag.Position      { return x.OpPos }
+func (x *BinaryExpr) Pos() diag.Position     { return x.X.Pos() }
+func (x *KeyValueExpr) Pos() diag.Position   { return x.Key.Pos() }
+func (x *ArrayType) Pos() diag.Position      { return x.Lbrack }
+func (x *Ellipsis) Pos() diag.Position       { return x.Ellipsis }
+func (x *StructType) Pos() diag.Position     { return x.Struct }
+func (x *FuncType) Pos() diag.Position       { return x.Func }
+func (x *InterfaceType) Pos() diag.Position  { return x.Interface }
+func (x *MapType) Pos() diag.Position        { return x.Map }
+func (x *ChanType) Pos() diag.Position       { return x.Begin }
+
+func (x *CompositeLit) Pos() diag.Position {
+	if x.Type != nil {
+		return x.Type.Pos()
+	}
+	return x.Lbrace
+}
+
+func (f *Field) Pos() diag.Position {
+	if len(f.Names) > 0 {
+		return f.Names[0].Pos()
+	}
+	return f.Type.Pos()
+}
+
+func (f *FieldList) Pos() diag.Position { return f.Opening }
+
+func (*BadExpr) exprNode()        {}
+func (*Ident) exprNode()          {}
+func (*BasicLit) exprNode()       {}
+func (*CompositeLit) exprNode()   {}
+func (*FuncLit) exprNode()        {}
+func (*ParenExpr) exprNode()      {}
+func (*SelectorExpr) exprNode()   {}
+func (*IndexExpr) exprNode()      {}
+func (*SliceExpr) exprNode()      {}
+func (*TypeAssertExpr) exprNode() {}
+func (*CallExpr) exprNode()       {}
+func (*StarExpr) exprNode()       {}
+func (*UnaryExpr) exprNode()      {}
+func (*BinaryExpr) exprNode()     {}
+func (*KeyValueExpr) exprNode()   {}
+func (*ArrayType) exprNode()      {}
+func (*Ellipsis) exprNode()       {}
+func (*StructType) exprNode()     {}
+func (*FuncType) exprNode()       {}
+func (*InterfaceType) exprNode()  {}
+func (*MapType) exprNode()        {}
+func (*ChanType) exprNode()       {}
+
+// IsBlank reports whether x is the blank identifier _.
+func IsBlank(x Expr) bool {
+	id, ok := x.(*Ident)
+	return ok && id.Name == "_"
+}
+
+// Unparen strips the parentheses around x.
+func Unparen(x Expr) Expr {
+	for {
+		paren, ok := x.(*ParenExpr)
+		if !ok {
+			return x
+		}
+		x = paren.X
+	}
+}
@@ -0,0 +1,84 @@
+package ast
+
+import (
+	"go/token"
+
+	"nano-go/diag"
+)
+
+type (
+	ImportSpec struct {
+		Name *Ident
+		Path *BasicLit
+	}
+
+	// ValueSpec is a spec of a const or var declaration. Iota is the
+	// index of a const spec within its declaration.
+	ValueSpec struct {
+		Names  []*Ident
+		Type   Expr
+		Values []Expr
+		Iota   int
+	}
+
+	// TypeSpec is a type definition, or an alias when Assign is valid.
+	TypeSpec struct {
+		Name   *Ident
+		Assign diag.Position
+		Type   Expr
+	}
+)
+
+type (
+	BadDecl struct {
+		From diag.Position
+	}
+
+	// GenDecl is an import, const, type or var declaration, Tok is
+	// token.IMPORT, token.CONST, token.TYPE or token.VAR.
+	GenDecl struct {
+		TokPos diag.Position
+		Tok    token.Token
+		Specs  []Spec
+	}
+
+	// FuncDecl is a function or, with Recv set, a method declaration.
+	// Body is nil for functions declared without one.
+	FuncDecl struct {
+		Recv *FieldList
+		Name *Ident
+		Type *FuncType
+		Body *BlockStmt
+	}
+)
+
+// File is a parsed source file.
+type File struct {
+	Filename string
+	Package  diag.Position
+	Name     *Ident
+	Imports  []*ImportSpec
+	Decls    []Decl
+}
+
+func (s *ImportSpec) Pos() diag.Position {
+	if s.Name != nil {
+		return s.Name.Pos()
+	}
+	return s.Path.Pos()
+}
+
+func (s *ValueSpec) Pos() diag.Position { return s.Names[0].Pos() }
+func (s *TypeSpec) Pos() diag.Position  { return s.Name.Pos() }
+func (d *BadDecl) Pos() diag.Position   { return d.From }
+func (d *GenDecl) Pos() diag.Position   { return d.TokPos }
+func (d *FuncDecl) Pos() diag.Position  { return d.Type.Pos() }
+func (f *File) Pos() diag.Position      { return f.Package }
+
+func (*ImportSpec) specNode() {}
+func (*ValueSpec) specNode()  {}
+func (*TypeSpec) specNode()   {}
+
+func (*BadDecl) declNode()  {}
+func (*GenDecl) declNode()  {}
+func (*FuncDecl) declNode() {}
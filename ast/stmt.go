@@ -0,0 +1,177 @@
+package ast
+
+import (
+	"go/token"
+
+	"nano-go/diag"
+)
+
+type (
+	// BadStmt is a placeholder for a statement that could not be built.
+	BadStmt struct {
+		From diag.Position
+	}
+
+	DeclStmt struct {
+		Decl Decl
+	}
+
+	EmptyStmt struct {
+		Semicolon diag.Position
+	}
+
+	LabeledStmt struct {
+		Label *Ident
+		Colon diag.Position
+		Stmt  Stmt
+	}
+
+	ExprStmt struct {
+		X Expr
+	}
+
+	SendStmt struct {
+		Chan  Expr
+		Arrow diag.Position
+		Value Expr
+	}
+
+	// IncDecStmt is X++ or X--, Tok is token.INC or token.DEC.
+	IncDecStmt struct {
+		X      Expr
+		TokPos diag.Position
+		Tok    token.Token
+	}
+
+	// AssignStmt is an assignment, Tok is token.ASSIGN, token.DEFINE or an
+	// op-assignment token such as token.ADD_ASSIGN.
+	AssignStmt struct {
+		Lhs    []Expr
+		TokPos diag.Position
+		Tok    token.Token
+		Rhs    []Expr
+	}
+
+	GoStmt struct {
+		Go   diag.Position
+		Call *CallExpr
+	}
+
+	DeferStmt struct {
+		Defer diag.Position
+		Call  *CallExpr
+	}
+
+	ReturnStmt struct {
+		Return  diag.Position
+		Results []Expr
+	}
+
+	// BranchStmt is break, continue, goto or fallthrough, Label is nil
+	// when the statement has none.
+	BranchStmt struct {
+		TokPos diag.Position
+		Tok    token.Token
+		Label  *Ident
+	}
+
+	BlockStmt struct {
+		Lbrace diag.Position
+		List   []Stmt
+		Rbrace diag.Position
+	}
+
+	// IfStmt is if [Init;] Cond Body [else Else], Else is a *BlockStmt or
+	// an *IfStmt.
+	IfStmt struct {
+		If   diag.Position
+		Init Stmt
+		Cond Expr
+		Body *BlockStmt
+		Else Stmt
+	}
+
+	// CaseClause is a case of an expression switch, List is nil for the
+	// default case.
+	CaseClause struct {
+		Case  diag.Position
+		List  []Expr
+		Colon diag.Position
+		Body  []Stmt
+	}
+
+	// SwitchStmt is an expression switch, Body holds only *CaseClauses.
+	SwitchStmt struct {
+		Switch diag.Position
+		Init   Stmt
+		Tag    Expr
+		Body   *BlockStmt
+	}
+
+	ForStmt struct {
+		For  diag.Position
+		Init Stmt
+		Cond Expr
+		Post Stmt
+		Body *BlockStmt
+	}
+
+	// RangeStmt is for Key, Value = range X, Tok is token.ILLEGAL when
+	// Key is nil, token.DEFINE or token.ASSIGN otherwise.
+	RangeStmt struct {
+		For    diag.Position
+		Key    Expr
+		Value  Expr
+		TokPos diag.Position
+		Tok    token.Token
+		X      Expr
+		Body   *BlockStmt
+	}
+)
+
+func (s *BadStmt) Pos() diag.Position     { return s.From }
+func (s *DeclStmt) Pos() diag.Position    { return s.Decl.Pos() }
+func (s *EmptyStmt) Pos() diag.Position   { return s.Semicolon }
+func (s *LabeledStmt) Pos() diag.Position { return s.Label.Pos() }
+func (s *ExprStmt) Pos() diag.Position    { return s.X.Pos() }
+func (s *SendStmt) Pos() diag.Position    { return s.Chan.Pos() }
+func (s *IncDecStmt) Pos() diag.Position  { return s.X.Pos() }
+func (s *AssignStmt) Pos() diag.Position  { return s.Lhs[0].Pos() }
+func (s *GoStmt) Pos() diag.Position      { return s.Go }
+func (s *DeferStmt) Pos() diag.Position   { return s.Defer }
+func (s *ReturnStmt) Pos() diag.Position  { return s.Return }
+func (s *BranchStmt) Pos() diag.Position  { return s.TokPos }
+func (s *BlockStmt) Pos() diag.Position   { return s.Lbrace }
+func (s *IfStmt) Pos() diag.Position      { return s.If }
+func (s *CaseClause) Pos() diag.Position  { return s.Case }
+func (s *SwitchStmt) Pos() diag.Position  { return s.Switch }
+func (s *ForStmt) Pos() diag.Position     { return s.For }
+func (s *RangeStmt) Pos() diag.Position   { return s.For }
+
+func (*BadStmt) stmtNode()     {}
+func (*DeclStmt) stmtNode()    {}
+func (*EmptyStmt) stmtNode()   {}
+func (*LabeledStmt) stmtNode() {}
+func (*ExprStmt) stmtNode()    {}
+func (*SendStmt) stmtNode()    {}
+func (*IncDecStmt) stmtNode()  {}
+func (*AssignStmt) stmtNode()  {}
+func (*GoStmt) stmtNode()      {}
+func (*DeferStmt) stmtNode()   {}
+func (*ReturnStmt) stmtNode()  {}
+func (*BranchStmt) stmtNode()  {}
+func (*BlockStmt) stmtNode()   {}
+func (*IfStmt) stmtNode()      {}
+func (*CaseClause) stmtNode()  {}
+func (*SwitchStmt) stmtNode()  {}
+func (*ForStmt) stmtNode()     {}
+func (*RangeStmt) stmtNode()   {}
+
+// Op returns the binary operator of an op-assignment, e.g. token.ADD for
+// x += y, and token.ILLEGAL for other assignments.
+func (s *AssignStmt) Op() token.Token {
+	if token.ADD_ASSIGN <= s.Tok && s.Tok <= token.AND_NOT_ASSIGN {
+		return s.Tok - token.ADD_ASSIGN + token.ADD
+	}
+	return token.ILLEGAL
+}
@@ -0,0 +1,357 @@
+package check
+
+import (
+	"fmt"
+
+	"nano-go/ast"
+)
+
+// implicitType returns the type the untyped operand x gets when it is used
+// where a value of type target is expected, nil if it cannot be converted.
+// When both are untyped, the numeric kinds rank int < rune < float.
+func implicitType(x *operand, target Type) Type {
+	if x.typ == Typ[UntypedNil] {
+		switch target.Underlying().(type) {
+		case *Slice, *Map:
+			return target
+		}
+	}
+
+	t, ok := target.Underlying().(*Basic)
+	if !ok {
+		return nil
+	}
+	kind := x.typ.(*Basic).kind
+
+	if t.is(IsUntyped) {
+		if isNumeric(x.typ) && t.is(IsNumeric) {
+			if kind > t.kind {
+				return x.typ
+			}
+			return target
+		}
+		if kind != t.kind {
+			return nil
+		}
+		return target
+	}
+
+	switch kind {
+	case UntypedBool:
+		ok = t.is(IsBoolean)
+	case UntypedInt, UntypedRune, UntypedFloat:
+		ok = t.is(IsNumeric)
+	case UntypedString:
+		ok = t.is(IsString)
+	default:
+		ok = false
+	}
+
+	if !ok {
+		return nil
+	}
+	return target
+}
+
+// setType converts the untyped operand x to typ.
+func (c *Checker) setType(x *operand, typ Type) {
+	c.updateExprType(x.expr, typ)
+	x.typ = typ
+
+	if x.mode == constant_ {
+		tv := c.info.Types[x.expr]
+		tv.Value = x.val
+		c.info.Types[x.expr] = tv
+	}
+}
+
+// updateExprType records typ as the type of the untyped expression e and of
+// the untyped operands it was computed from.
+func (c *Checker) updateExprType(e ast.Expr, typ Type) {
+	tv, ok := c.info.Types[e]
+	if !ok || !isUntyped(tv.Type) {
+		return
+	}
+
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		c.updateExprType(e.X, typ)
+	case *ast.UnaryExpr:
+		c.updateExprType(e.X, typ)
+	case *ast.BinaryExpr:
+		switch {
+		case isComparison(e.Op):
+			// The operands were converted to each other's types, the
+			// result type does not affect them.
+		case isShift(e.Op):
+			c.updateExprType(e.X, typ)
+		default:
+			c.updateExprType(e.X, typ)
+			c.updateExprType(e.Y, typ)
+		}
+	}
+
+	// The left operand of a non-constant shift must get an integer type
+	// that can represent it.
+	if c.shiftOperands[e] && !isUntyped(typ) {
+		delete(c.shiftOperands, e)
+		if !isInteger(typ) {
+			c.errorf(e, "invalid operation: shifted operand %s (type %s) must be integer", ExprString(e), typ)
+			return
+		}
+		x := operand{mode: tv.mode, expr: e, typ: tv.Type, val: tv.Value}
+		c.convertUntyped(&x, typ)
+		return
+	}
+
+	tv.Type = typ
+	c.info.Types[e] = tv
+}
+
+// assignment checks that x can be assigned to a variable of type T in the
+// given context and converts x if it is untyped. A nil T stands for a
+// variable whose type is inferred, x gets its default type then.
+func (c *Checker) assignment(x *operand, T Type, context string) {
+	if x.mode == invalid || T == Typ[Invalid] {
+		return
+	}
+
+	if isUntyped(x.typ) {
+		target := T
+		if T == nil {
+			if x.typ == Typ[UntypedNil] {
+				c.errorf(x.expr, "use of untyped nil in %s", context)
+				x.mode = invalid
+				return
+			}
+			target = Default(x.typ)
+		}
+
+		if !c.convertUntyped(x, target) {
+			c.errorf(x.expr, "cannot use %s as %s value in %s", x, target, context)
+			x.mode = invalid
+		}
+		if x.mode == invalid {
+			return
+		}
+	}
+
+	if T != nil && !assignableTo(x, T) {
+		c.errorf(x.expr, "cannot use %s as %s value in %s", x, T, context)
+		x.mode = invalid
+	}
+}
+
+// assignableTo reports whether x can be assigned to a variable of type T.
+// Values of types with the same underlying type can be assigned to each
+// other when one of the types is not named.
+func assignableTo(x *operand, T Type) bool {
+	if Identical(x.typ, T) {
+		return true
+	}
+	return (!isNamed(x.typ) || !isNamed(T)) && Identical(x.typ.Underlying(), T.Underlying())
+}
+
+// lhsVar checks the left-hand side of an assignment and returns its type,
+// nil for the blank identifier.
+func (c *Checker) lhsVar(e ast.Expr) Type {
+	// Assigning to a variable does not use it.
+	var v *Var
+	var used bool
+	if id, ok := e.(*ast.Ident); ok {
+		if id.Name == "_" {
+			return nil
+		}
+		if obj, ok := c.scope.LookupParent(id.Name).(*Var); ok {
+			v, used = obj, obj.used
+		}
+	}
+
+	var x operand
+	c.expr(&x, e)
+	if v != nil {
+		v.used = used
+	}
+
+	switch x.mode {
+	case invalid:
+		return Typ[Invalid]
+	case variable, mapindex:
+		return x.typ
+	}
+
+	if sel, ok := e.(*ast.SelectorExpr); ok && c.info.Types[sel.X].mode == mapindex {
+		c.errorf(e, "cannot assign to struct field %s in map", ExprString(e))
+		return Typ[Invalid]
+	}
+	c.errorf(e, "cannot assign to %s (neither addressable nor a map index expression)", &x)
+	return Typ[Invalid]
+}
+
+// unpack checks the right-hand side of an assignment to n variables and
+// returns one operand per variable, nil after an error. A map index assigned
+// to two variables also reports whether the key is present, its type is
+// recorded as a tuple then.
+func (c *Checker) unpack(n int, rhs []ast.Expr, at ast.Node) []*operand {
+	ops := c.exprList(rhs)
+	if len(ops) == n {
+		return ops
+	}
+
+	if n == 2 && len(ops) == 1 && ops[0].mode == mapindex {
+		x := ops[0]
+		x.mode = value
+		// The errors about ok name it, like the ones of go/types.
+		dummy := &ast.Ident{NamePos: x.expr.Pos(), Name: "ok value of (comma, ok) expression"}
+		ok := &operand{mode: value, expr: dummy, typ: Typ[UntypedBool]}
+
+		tv := c.info.Types[x.expr]
+		tv.Type = NewTuple(NewVar(noPos, "", x.typ), NewVar(noPos, "", Typ[Bool]))
+		c.info.Types[x.expr] = tv
+		return []*operand{x, ok}
+	}
+
+	for _, x := range ops {
+		if x.mode == invalid {
+			return nil
+		}
+	}
+
+	if call, ok := unparen(rhs[0]).(*ast.CallExpr); ok && len(rhs) == 1 {
+		c.errorf(at, "assignment mismatch: %s but %s returns %s",
+			plural(n, "variable"), ExprString(call), plural(len(ops), "value"))
+		return nil
+	}
+
+	c.errorf(at, "assignment mismatch: %s but %s", plural(n, "variable"), plural(len(ops), "value"))
+	return nil
+}
+
+func (c *Checker) assignVars(s *ast.AssignStmt) {
+	lhs := make([]Type, len(s.Lhs))
+	for i, e := range s.Lhs {
+		lhs[i] = c.lhsVar(e)
+	}
+
+	ops := c.unpack(len(s.Lhs), s.Rhs, s)
+	if ops == nil {
+		return
+	}
+
+	for i, x := range ops {
+		c.assignment(x, lhs[i], "assignment")
+	}
+}
+
+// assignOp checks the op-assignment x op= y like the assignment x = x op y.
+func (c *Checker) assignOp(s *ast.AssignStmt) {
+	if len(s.Lhs) != 1 || len(s.Rhs) != 1 {
+		c.errorf(s, "assignment operation %s requires single-valued expressions", s.Tok)
+		return
+	}
+
+	T := c.lhsVar(s.Lhs[0])
+	if T == nil {
+		c.errorf(s.Lhs[0], "cannot use _ as value")
+		return
+	}
+
+	var x operand
+	c.binary(&x, &ast.BinaryExpr{X: s.Lhs[0], OpPos: s.TokPos, Op: s.Op(), Y: s.Rhs[0]})
+	if x.mode == invalid {
+		return
+	}
+
+	c.assignment(&x, T, "assignment")
+}
+
+func (c *Checker) shortVarDecl(s *ast.AssignStmt) {
+	vars := make([]*Var, len(s.Lhs))
+	var newVars []*Var
+	hasErr := false
+
+	seen := make(map[string]bool)
+	for i, e := range s.Lhs {
+		id, ok := e.(*ast.Ident)
+		if !ok {
+			c.errorf(e, "non-name %s on left side of :=", ExprString(e))
+			hasErr = true
+			continue
+		}
+
+		name := id.Name
+		if name != "_" {
+			if seen[name] {
+				c.errorf(id, "%s repeated on left side of :=", name)
+				hasErr = true
+				continue
+			}
+			seen[name] = true
+
+			// Variables declared in the same scope are assigned to.
+			if alt := c.scope.Lookup(name); alt != nil {
+				c.info.Uses[id] = alt
+				if v, ok := alt.(*Var); ok {
+					vars[i] = v
+				} else {
+					c.errorf(id, "cannot assign to %s", name)
+					hasErr = true
+				}
+				continue
+			}
+		}
+
+		v := NewVar(id.Pos(), name, nil)
+		vars[i] = v
+		c.info.Defs[id] = v
+		if name != "_" {
+			newVars = append(newVars, v)
+		}
+	}
+
+	ops := c.unpack(len(s.Lhs), s.Rhs, s)
+
+	for i, v := range vars {
+		switch {
+		case v == nil:
+		case v.typ != nil:
+			if ops != nil {
+				c.assignment(ops[i], v.typ, "assignment")
+			}
+		default:
+			v.typ = Typ[Invalid]
+			if ops != nil {
+				c.assignment(ops[i], nil, "assignment")
+				if ops[i].mode != invalid {
+					v.typ = ops[i].typ
+				}
+			}
+		}
+	}
+
+	// The new variables are in scope after the statement only.
+	for _, v := range newVars {
+		c.scope.Insert(v)
+	}
+
+	if len(newVars) == 0 && !hasErr {
+		c.errorf(s, "no new variables on left side of :=")
+	}
+}
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
+
+func plural(n int, what string) string {
+	if n == 1 {
+		return "1 " + what
+	}
+	return fmt.Sprintf("%d %ss", n, what)
+}
@@ -0,0 +1,257 @@
+package check
+
+import (
+	"go/constant"
+
+	"nano-go/ast"
+)
+
+// builtin checks the call of the builtin function id.
+func (c *Checker) builtin(x *operand, call *ast.CallExpr, id builtinID) {
+	name := ExprString(call.Fun)
+	x.mode = novalue
+
+	if call.Ellipsis && id != _Append {
+		c.errorf(call, "invalid use of ... with built-in %s", name)
+		c.use(call.Args...)
+		return
+	}
+
+	// The first argument of make is a type.
+	if id == _Make {
+		c.makeCall(x, call)
+		return
+	}
+
+	args := c.exprList(call.Args)
+
+	switch id {
+	case _Print:
+		if len(args) != 1 {
+			c.errorf(call, "wrong number of arguments in call to %s, it takes one string", name)
+			return
+		}
+		c.assignment(args[0], Typ[String], "argument to "+name)
+
+	case _Printf:
+		if len(args) == 0 {
+			c.errorf(call, "not enough arguments in call to %s", name)
+			return
+		}
+		c.assignment(args[0], Typ[String], "argument to "+name)
+
+		for _, arg := range args[1:] {
+			c.assignment(arg, nil, "argument to "+name)
+			if arg.mode != invalid && !isNumeric(arg.typ) {
+				c.errorf(arg.expr, "cannot use %s as argument to %s, only numbers are implemented yet", arg, name)
+			}
+		}
+
+	case _Len, _Cap:
+		if !c.argCount(call, args, 1, false) {
+			x.mode = invalid
+			return
+		}
+
+		arg := args[0]
+		if arg.mode == invalid {
+			x.mode = invalid
+			return
+		}
+
+		mode := invalid
+		var val constant.Value
+		switch t := arg.typ.Underlying().(type) {
+		case *Basic:
+			if t.is(IsString) && id == _Len {
+				mode = value
+				if arg.mode == constant_ {
+					mode = constant_
+					val = constant.MakeInt64(int64(len(constant.StringVal(arg.val))))
+				}
+			}
+		case *Array:
+			// The length of an array is constant unless the expression
+			// calls functions.
+			mode = value
+			if !c.hasCall(arg.expr) {
+				mode = constant_
+				val = constant.MakeInt64(t.len)
+			}
+		case *Slice:
+			mode = value
+		case *Map:
+			if id == _Len {
+				mode = value
+			}
+		}
+
+		if mode == invalid {
+			c.errorf(arg.expr, "invalid argument: %s for built-in %s", arg, name)
+			x.mode = invalid
+			return
+		}
+
+		x.mode = mode
+		x.typ = Typ[Int]
+		x.val = val
+
+	case _Append:
+		if !c.argCount(call, args, 1, true) || args[0].mode == invalid {
+			x.mode = invalid
+			return
+		}
+
+		s := args[0]
+		slice, ok := s.typ.Underlying().(*Slice)
+		if !ok {
+			c.errorf(s.expr, "invalid append: argument must be a slice; have %s", s)
+			x.mode = invalid
+			return
+		}
+
+		x.mode = value
+		x.typ = s.typ
+
+		// append(b, str...) appends the bytes of a string to a []byte.
+		if len(args) == 2 && call.Ellipsis && Identical(slice.elem, Typ[Byte]) {
+			if str := args[1]; str.mode != invalid && isString(str.typ) {
+				return
+			}
+		}
+
+		sig := NewSignature(
+			NewTuple(NewVar(noPos, "", s.typ), NewVar(noPos, "", NewSlice(slice.elem))),
+			NewTuple(NewVar(noPos, "", s.typ)),
+			true,
+		)
+		c.arguments(call, sig, args)
+
+	case _Copy:
+		if !c.argCount(call, args, 2, false) || args[0].mode == invalid || args[1].mode == invalid {
+			x.mode = invalid
+			return
+		}
+
+		dst, src := args[0], args[1]
+		dstSlice, ok := dst.typ.Underlying().(*Slice)
+		if !ok {
+			c.errorf(dst.expr, "invalid copy: argument must be a slice; have %s", dst)
+			x.mode = invalid
+			return
+		}
+
+		srcSlice, ok := src.typ.Underlying().(*Slice)
+		if isString(src.typ) {
+			// The bytes of a string can be copied to a []byte.
+			srcSlice, ok = NewSlice(Typ[Byte]), true
+		}
+		if !ok {
+			c.errorf(src.expr, "invalid copy: argument must be a slice; have %s", src)
+			x.mode = invalid
+			return
+		}
+
+		if !Identical(dstSlice.elem, srcSlice.elem) {
+			c.errorf(dst.expr, "invalid copy: arguments %s and %s have different element types %s and %s",
+				dst, src, dstSlice.elem, srcSlice.elem)
+			x.mode = invalid
+			return
+		}
+
+		x.mode = value
+		x.typ = Typ[Int]
+
+	case _Delete:
+		if !c.argCount(call, args, 2, false) || args[0].mode == invalid {
+			return
+		}
+
+		m, ok := args[0].typ.Underlying().(*Map)
+		if !ok {
+			c.errorf(args[0].expr, "invalid argument: %s is not a map", args[0])
+			return
+		}
+		c.assignment(args[1], m.key, "argument to delete")
+	}
+}
+
+// argCount checks that the builtin call has n arguments, or more when the
+// builtin is variadic.
+func (c *Checker) argCount(call *ast.CallExpr, args []*operand, n int, variadic bool) bool {
+	var qualifier string
+	switch {
+	case len(args) < n:
+		qualifier = "not enough"
+	case len(args) > n && !variadic:
+		qualifier = "too many"
+	default:
+		return true
+	}
+
+	c.errorf(call, "invalid operation: %s arguments for %s (expected %d, found %d)", qualifier, ExprString(call), n, len(args))
+	return false
+}
+
+// makeCall checks a call of make, which makes a slice of a length and an
+// optional capacity, or a map with an optional size hint.
+func (c *Checker) makeCall(x *operand, call *ast.CallExpr) {
+	if len(call.Args) == 0 {
+		c.errorf(call, "invalid operation: not enough arguments for %s (expected 1, found 0)", ExprString(call))
+		x.mode = invalid
+		return
+	}
+
+	typ := c.typExpr(call.Args[0])
+	if typ == Typ[Invalid] {
+		c.use(call.Args[1:]...)
+		x.mode = invalid
+		return
+	}
+
+	var min int
+	switch typ.Underlying().(type) {
+	case *Slice:
+		min = 2
+	case *Map:
+		min = 1
+	default:
+		c.errorf(call.Args[0], "invalid argument: cannot make %s: type must be slice, map, or channel", ExprString(call.Args[0]))
+		c.use(call.Args[1:]...)
+		x.mode = invalid
+		return
+	}
+
+	if n := len(call.Args); n < min || n > min+1 {
+		c.errorf(call, "invalid operation: %s expects %d or %d arguments; found %d", ExprString(call), min, min+1, n)
+		c.use(call.Args[1:]...)
+		x.mode = invalid
+		return
+	}
+
+	var sizes []int64
+	for _, arg := range call.Args[1:] {
+		if t, size := c.index(arg, -1); t != Typ[Invalid] && size >= 0 {
+			sizes = append(sizes, size)
+		}
+	}
+	if len(sizes) == 2 && sizes[0] > sizes[1] {
+		c.errorf(call.Args[1], "invalid argument: length and capacity swapped")
+	}
+
+	x.mode = value
+	x.typ = typ
+}
+
+// hasCall reports whether e calls a function whose result is not constant.
+func (c *Checker) hasCall(e ast.Expr) bool {
+	found := false
+	ast.Inspect(e, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if ok && c.info.Types[call].Value == nil && !c.info.Types[call.Fun].IsType() {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
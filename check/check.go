@@ -0,0 +1,173 @@
+// Package check resolves the identifiers of a file and type-checks it
+// following the Go spec. The code generator only runs on files that passed
+// the check, it relies on the types and objects recorded in Info.
+package check
+
+import (
+	"go/constant"
+
+	"nano-go/ast"
+	"nano-go/diag"
+)
+
+// Info holds the results of the check of a file.
+type Info struct {
+	// Types maps expressions to their types. Untyped expressions that were
+	// implicitly converted are recorded with the type they were converted
+	// to.
+	Types map[ast.Expr]TypeAndValue
+
+	// Defs maps identifiers to the objects they declare.
+	Defs map[*ast.Ident]Object
+
+	// Uses maps identifiers to the objects they denote.
+	Uses map[*ast.Ident]Object
+
+	// InitOrder lists the initializers of the package-level variables in
+	// the order they must run: a variable is initialized after the
+	// variables its init refers to, otherwise in source order. References
+	// made through function bodies are not followed.
+	InitOrder []*Initializer
+}
+
+// Initializer is the init of one or more package-level variables. Rhs is
+// multi-valued when it initializes several variables.
+type Initializer struct {
+	Lhs []*Var
+	Rhs ast.Expr
+}
+
+// TypeAndValue describes an expression. Value is set for constant
+// expressions, in the representation of Type.
+type TypeAndValue struct {
+	mode  operandMode
+	Type  Type
+	Value constant.Value
+}
+
+// IsType reports whether the expression denotes a type.
+func (tv TypeAndValue) IsType() bool { return tv.mode == typexpr }
+
+// IsBuiltin reports whether the expression denotes a builtin function.
+func (tv TypeAndValue) IsBuiltin() bool { return tv.mode == builtin }
+
+// Addressable reports whether the expression is a variable, or an element
+// of one, whose address can be taken.
+func (tv TypeAndValue) Addressable() bool { return tv.mode == variable }
+
+// IsVoid reports whether the expression is a call without results.
+func (tv TypeAndValue) IsVoid() bool { return tv.mode == novalue }
+
+// TypeOf returns the type of e, nil if it is unknown.
+func (info *Info) TypeOf(e ast.Expr) Type {
+	if tv, ok := info.Types[e]; ok {
+		return tv.Type
+	}
+	return nil
+}
+
+// ObjectOf returns the object id declares or denotes, nil if it is unknown.
+func (info *Info) ObjectOf(id *ast.Ident) Object {
+	if obj := info.Defs[id]; obj != nil {
+		return obj
+	}
+	return info.Uses[id]
+}
+
+// Checker holds the state of the check of a file.
+type Checker struct {
+	info  *Info
+	diags *diag.List
+
+	pkg   *Scope
+	scope *Scope
+
+	// objMap holds the package-level objects whose declarations are not
+	// resolved yet, objList has all of them in source order.
+	objMap    map[Object]*declInfo
+	objList   []Object
+	resolving map[Object]bool
+
+	// sig is the signature of the function whose body is checked.
+	sig *Signature
+
+	// iota is the value of iota in the constant declaration being
+	// checked, nil outside of constant declarations.
+	iota constant.Value
+
+	// shiftOperands are the untyped constant left operands of non-constant
+	// shifts, they are checked once the context gives them their type.
+	shiftOperands map[ast.Expr]bool
+}
+
+// Check type-checks file and reports the errors it finds to diags. The
+// returned Info is only complete when no errors were reported.
+func Check(file *ast.File, diags *diag.List) *Info {
+	c := &Checker{
+		info: &Info{
+			Types: make(map[ast.Expr]TypeAndValue),
+			Defs:  make(map[*ast.Ident]Object),
+			Uses:  make(map[*ast.Ident]Object),
+		},
+		diags:     diags,
+		pkg:       NewScope(Universe),
+		objMap:    make(map[Object]*declInfo),
+		resolving: make(map[Object]bool),
+
+		shiftOperands: make(map[ast.Expr]bool),
+	}
+	c.scope = c.pkg
+
+	c.file(file)
+
+	return c.info
+}
+
+var noPos diag.Position
+
+func (c *Checker) errorf(node ast.Node, format string, args ...interface{}) {
+	c.diags.Errorf(node.Pos(), format, args...)
+}
+
+func (c *Checker) openScope() {
+	c.scope = NewScope(c.scope)
+}
+
+func (c *Checker) closeScope() {
+	c.usage(c.scope)
+	c.scope = c.scope.Parent()
+}
+
+// usage reports the variables of scope that are never used.
+func (c *Checker) usage(scope *Scope) {
+	for _, obj := range scope.elems {
+		if v, ok := obj.(*Var); ok && !v.used {
+			c.diags.Errorf(v.pos, "declared and not used: %s", v.name)
+		}
+	}
+}
+
+// declare inserts obj into scope and records it as the object id defines.
+// The blank identifier declares nothing.
+func (c *Checker) declare(scope *Scope, id *ast.Ident, obj Object) {
+	c.info.Defs[id] = obj
+
+	if obj.Name() == "_" {
+		return
+	}
+
+	if alt := scope.Insert(obj); alt != nil {
+		c.errorf(id, "%s redeclared in this block", obj.Name())
+		if alt.Pos().IsValid() {
+			c.diags.Notef(alt.Pos(), "other declaration of %s", obj.Name())
+		}
+	}
+}
+
+func (c *Checker) record(x *operand) {
+	var val constant.Value
+	if x.mode == constant_ {
+		val = x.val
+	}
+	c.info.Types[x.expr] = TypeAndValue{mode: x.mode, Type: x.typ, Value: val}
+}
@@ -0,0 +1,342 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"nano-go/diag"
+	"nano-go/parser"
+)
+
+var diagnosticTests = []struct {
+	name string
+	src  string
+	want []string
+}{
+	{
+		name: "undefined",
+		src: `package main
+
+func main() {
+	x := y + 1
+	_ = x
+}
+`,
+		want: []string{
+			"4:7: error: undefined: y",
+		},
+	},
+	{
+		name: "unused variable",
+		src: `package main
+
+func main() {
+	x := 1
+}
+`,
+		want: []string{
+			"4:2: error: declared and not used: x",
+		},
+	},
+	{
+		name: "mismatched types",
+		src: `package main
+
+func main() {
+	var a int = 1
+	var b float64 = 2
+	_ = a + b
+}
+`,
+		want: []string{
+			"6:6: error: invalid operation: a + b (mismatched types int and float64)",
+		},
+	},
+	{
+		name: "argument",
+		src: `package main
+
+func add(a, b int) int {
+	return a + b
+}
+
+func main() {
+	_ = add(1, "2")
+}
+`,
+		want: []string{
+			`8:13: error: cannot use "2" (untyped string constant) as int value in argument to add`,
+		},
+	},
+	{
+		name: "missing return",
+		src: `package main
+
+func f(x int) int {
+	if x > 0 {
+		return 1
+	}
+}
+
+func main() {
+	_ = f(1)
+}
+`,
+		want: []string{
+			"7:1: error: missing return",
+		},
+	},
+	{
+		name: "untyped shift operand",
+		src: `package main
+
+func main() {
+	var s uint
+	var u uint8 = 1 << s
+	var x int64 = 1 << s
+	var f float64 = 1 << s
+	var o uint8 = 256 << s
+	g := 1.0 << s
+	_, _, _, _, _ = u, x, f, o, g
+	_ = float64(1 << s)
+}
+`,
+		want: []string{
+			"7:18: error: invalid operation: shifted operand 1 (type float64) must be integer",
+			"8:16: error: 256 (untyped int constant) overflows uint8",
+			"9:7: error: invalid operation: shifted operand 1.0 (type float64) must be integer",
+			"11:14: error: invalid operation: shifted operand 1 (type float64) must be integer",
+		},
+	},
+	{
+		name: "comparable composites",
+		src: `package main
+
+type Person struct {
+	name string
+	age  int
+}
+
+func main() {
+	p := Person{"ann", 1}
+	a := [2]string{"x", "y"}
+	_ = p == Person{"bob", 2}
+	_ = a != [2]string{}
+}
+`,
+	},
+	{
+		name: "incomparable",
+		src: `package main
+
+type T struct {
+	xs []int
+}
+
+func main() {
+	var s []int
+	var t T
+	_ = s == s
+	_ = t == t
+	_ = [1]T{} == [1]T{}
+}
+`,
+		want: []string{
+			"10:6: error: invalid operation: s == s (slice can only be compared to nil)",
+			"11:6: error: invalid operation: t == t (struct containing []int cannot be compared)",
+			"12:6: error: invalid operation: [1]T{} == [1]T{} ([1]T cannot be compared)",
+		},
+	},
+	{
+		name: "nil",
+		src: `package main
+
+func main() {
+	var s []int = nil
+	m := map[string]int(nil)
+	_ = s == nil
+	_ = nil != m
+	m = nil
+	var x int
+	_ = x == nil
+	_ = nil == nil
+	y := nil
+	x = nil
+	var a [2]int
+	_ = a == nil
+	switch s {
+	case nil, []int{}:
+	}
+}
+`,
+		want: []string{
+			"10:11: error: invalid operation: x == nil (mismatched types int and untyped nil)",
+			"11:13: error: invalid operation: nil == nil (operator == not defined on untyped nil)",
+			"12:2: error: declared and not used: y",
+			"12:7: error: use of untyped nil in assignment",
+			"13:6: error: cannot use nil as int value in assignment",
+			"15:11: error: invalid operation: a == nil (mismatched types [2]int and untyped nil)",
+			"17:12: error: invalid case []int{} in switch on s (slice can only be compared to nil)",
+		},
+	},
+	{
+		name: "string comparison",
+		src: `package main
+
+func main() {
+	s := "a"
+	_ = s == "b"
+	_ = s != "b"
+	_ = "a" < "b"
+	_ = s < "b"
+}
+`,
+		want: []string{
+			"8:6: error: comparison of strings is not implemented yet",
+		},
+	},
+	{
+		name: "struct literal",
+		src: `package main
+
+type Pt struct {
+	x, y int
+}
+
+func main() {
+	_ = Pt{z: 1}
+	_ = Pt{x: 1, x: 2}
+	_ = Pt{1}
+	_ = Pt{1, 2, 3}
+	_ = Pt{x: 1, 2}
+	var p Pt
+	_ = p.z
+}
+`,
+		want: []string{
+			"8:9: error: unknown field z in struct literal of type Pt",
+			"9:15: error: duplicate field name x in struct literal",
+			"10:10: error: too few values in struct literal of type Pt",
+			"11:15: error: too many values in struct literal of type Pt",
+			"12:15: error: mixture of field:value and value elements in struct literal",
+			"14:8: error: p.z undefined (type Pt has no field or method z)",
+		},
+	},
+	{
+		name: "recursive types",
+		src: `package main
+
+type A struct {
+	b B
+}
+
+type B struct {
+	a A
+}
+
+type C C
+
+func main() {
+}
+`,
+		want: []string{
+			"3:6: error: invalid recursive type A",
+			"3:6: note: A refers to B",
+			"7:6: note: B refers to A",
+			"11:6: error: invalid recursive type: C refers to itself",
+		},
+	},
+	{
+		name: "maps",
+		src: `package main
+
+type Pt struct {
+	x int
+}
+
+func main() {
+	m := map[string]Pt{}
+	m["a"].x = 1
+	var k map[[]int]int
+	_ = k
+}
+`,
+		want: []string{
+			`9:2: error: cannot assign to struct field m["a"].x in map`,
+			"10:12: error: invalid map key type []int",
+		},
+	},
+	{
+		name: "function values",
+		src: `package main
+
+func one() int {
+	return 1
+}
+
+func main() {
+	f := one
+	_ = f
+	Printf("%d\n", one)
+	Printf("%d\n", one())
+}
+`,
+		want: []string{
+			"8:7: error: function values are not implemented yet",
+			"10:17: error: function values are not implemented yet",
+		},
+	},
+	{
+		name: "imports",
+		src: `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+}
+`,
+		want: []string{
+			"4:2: error: imports are not implemented yet",
+			"5:2: error: imports are not implemented yet",
+		},
+	},
+	{
+		name: "not implemented",
+		src: `package main
+
+func main() {
+	var p *int
+	_ = p
+}
+`,
+		want: []string{
+			"4:8: error: pointer types is not implemented yet",
+		},
+	},
+}
+
+func TestDiagnostics(t *testing.T) {
+	for _, test := range diagnosticTests {
+		t.Run(test.name, func(t *testing.T) {
+			diags := &diag.List{}
+			file := parser.ParseFile("test.go", test.src, diags)
+			if file == nil {
+				t.Fatalf("syntax errors: %v", diags.Diagnostics())
+			}
+			Check(file, diags)
+
+			var got []string
+			for _, d := range diags.Diagnostics() {
+				got = append(got, fmt.Sprintf("%d:%d: %s: %s", d.Pos.Line, d.Pos.Column, d.Severity, d.Message))
+			}
+
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package check
+
+import (
+	"go/constant"
+	"go/token"
+	"math"
+)
+
+// shiftBound is the largest count of a constant shift. Larger shifts would
+// only produce absurdly large untyped constants.
+const shiftBound = 1023 - 1 + 52
+
+// maxUntypedBits limits the precision of untyped integer constants.
+const maxUntypedBits = 512
+
+// representableConst reports whether the value x can be represented by the
+// basic type typ. If rounded is not nil, it is set to x in the
+// representation of typ.
+func representableConst(x constant.Value, typ *Basic, rounded *constant.Value) bool {
+	if x.Kind() == constant.Unknown {
+		return true
+	}
+
+	switch {
+	case typ.is(IsInteger):
+		x := constant.ToInt(x)
+		if x.Kind() != constant.Int {
+			return false
+		}
+		if rounded != nil {
+			*rounded = x
+		}
+
+		if x, ok := constant.Int64Val(x); ok {
+			switch typ.kind {
+			case Int8:
+				return math.MinInt8 <= x && x <= math.MaxInt8
+			case Int16:
+				return math.MinInt16 <= x && x <= math.MaxInt16
+			case Int32, UntypedRune:
+				return math.MinInt32 <= x && x <= math.MaxInt32
+			case Int, Int64, UntypedInt:
+				return true
+			case Uint8:
+				return 0 <= x && x <= math.MaxUint8
+			case Uint16:
+				return 0 <= x && x <= math.MaxUint16
+			case Uint32:
+				return 0 <= x && x <= math.MaxUint32
+			case Uint, Uint64, Uintptr:
+				return 0 <= x
+			}
+			return false
+		}
+
+		// x does not fit into an int64.
+		switch typ.kind {
+		case Uint, Uint64, Uintptr:
+			return constant.Sign(x) >= 0 && constant.BitLen(x) <= 64
+		case UntypedInt:
+			return true
+		}
+
+	case typ.is(IsFloat):
+		x := constant.ToFloat(x)
+		if x.Kind() != constant.Float {
+			return false
+		}
+
+		var r constant.Value
+		switch typ.kind {
+		case Float32:
+			r = roundFloat32(x)
+		case Float64:
+			r = roundFloat64(x)
+		case UntypedFloat:
+			r = x
+		}
+		if r == nil {
+			return false
+		}
+		if rounded != nil {
+			*rounded = r
+		}
+		return true
+
+	case typ.is(IsString):
+		return x.Kind() == constant.String
+
+	case typ.is(IsBoolean):
+		return x.Kind() == constant.Bool
+	}
+
+	return false
+}
+
+// roundFloat32 rounds x to a float32, nil if it overflows.
+func roundFloat32(x constant.Value) constant.Value {
+	f32, _ := constant.Float32Val(x)
+	f := float64(f32)
+	if !math.IsInf(f, 0) {
+		return constant.MakeFloat64(f)
+	}
+	return nil
+}
+
+// roundFloat64 rounds x to a float64, nil if it overflows.
+func roundFloat64(x constant.Value) constant.Value {
+	f, _ := constant.Float64Val(x)
+	if !math.IsInf(f, 0) {
+		return constant.MakeFloat64(f)
+	}
+	return nil
+}
+
+// convertUntyped converts the untyped operand x to target. It reports false
+// when the types do not match, the caller reports that. A constant that
+// target cannot represent is reported here and invalidates x.
+func (c *Checker) convertUntyped(x *operand, target Type) bool {
+	t := implicitType(x, target)
+	if t == nil {
+		return false
+	}
+
+	if b, ok := t.Underlying().(*Basic); ok && x.mode == constant_ && !b.is(IsUntyped) {
+		rounded := x.val
+		if !representableConst(x.val, b, &rounded) {
+			c.errorf(x.expr, "%s %s %s", x, notRepresentable(x, b), t)
+			x.mode = invalid
+			return true
+		}
+		x.val = rounded
+	}
+
+	c.setType(x, t)
+	return true
+}
+
+// notRepresentable describes why the constant x does not fit typ.
+func notRepresentable(x *operand, typ *Basic) string {
+	if typ.is(IsInteger) && x.val.Kind() == constant.Float {
+		return "truncated to"
+	}
+	return "overflows"
+}
+
+// overflow checks that the result of a constant operation fits its type.
+func (c *Checker) overflow(x *operand) {
+	if x.val.Kind() == constant.Unknown {
+		c.errorf(x.expr, "constant result is not representable")
+		x.mode = invalid
+		return
+	}
+
+	if b, ok := x.typ.Underlying().(*Basic); ok && !b.is(IsUntyped) {
+		if !representableConst(x.val, b, &x.val) {
+			c.errorf(x.expr, "constant %s overflows %s", x.val, x.typ)
+			x.mode = invalid
+		}
+		return
+	}
+
+	if x.val.Kind() == constant.Int && constant.BitLen(x.val) > maxUntypedBits {
+		c.errorf(x.expr, "constant %s overflow", ExprString(x.expr))
+		x.mode = invalid
+	}
+}
+
+// foldBinary computes the constant result of the arithmetic operation op on
+// the constants x and y of the same type.
+func foldBinary(x, y *operand, op token.Token) constant.Value {
+	// Integer constants are divided like integer values.
+	if op == token.QUO && isInteger(x.typ) {
+		op = token.QUO_ASSIGN
+	}
+	return constant.BinaryOp(x.val, op, y.val)
+}
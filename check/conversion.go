@@ -0,0 +1,82 @@
+package check
+
+import (
+	"go/constant"
+
+	"nano-go/ast"
+)
+
+// conversion checks the conversion T(x) of the call and sets x to its
+// result. The conversion of a constant to a constant type is constant.
+func (c *Checker) conversion(x *operand, call *ast.CallExpr, T Type) {
+	switch n := len(call.Args); {
+	case n == 0:
+		c.errorf(call, "missing argument in conversion to %s", T)
+		x.mode = invalid
+		return
+	case n > 1:
+		c.exprList(call.Args)
+		c.errorf(call.Args[n-1], "too many arguments in conversion to %s", T)
+		x.mode = invalid
+		return
+	}
+
+	if call.Ellipsis {
+		c.errorf(call, "invalid use of ... in conversion to %s", T)
+	}
+
+	c.expr(x, call.Args[0])
+	if x.mode == invalid {
+		return
+	}
+
+	if isInteger(x.typ) && isString(T) {
+		c.errorf(call, "conversion from %s to string is not implemented yet", x.typ)
+		x.mode = invalid
+		return
+	}
+
+	if !convertibleTo(x, T) {
+		c.errorf(call, "cannot convert %s to type %s", x, T)
+		x.mode = invalid
+		return
+	}
+
+	if x.mode == constant_ && isConstType(T) {
+		t := T.Underlying().(*Basic)
+		val := x.val
+		if !representableConst(val, t, &val) {
+			if t.is(IsInteger) && constant.ToInt(val).Kind() != constant.Int {
+				c.errorf(call, "cannot convert %s to type %s (truncated)", x, T)
+			} else {
+				c.errorf(call, "cannot convert %s to type %s (overflows)", x, T)
+			}
+			x.mode = invalid
+			return
+		}
+		x.val = val
+	} else {
+		x.mode = value
+	}
+
+	// An untyped argument gets the type it is converted to.
+	if isUntyped(x.typ) {
+		c.updateExprType(x.expr, T)
+	}
+
+	x.expr = call
+	x.typ = T
+}
+
+// convertibleTo reports whether a value x can be converted to type T.
+func convertibleTo(x *operand, T Type) bool {
+	if Identical(x.typ.Underlying(), T.Underlying()) {
+		return true
+	}
+
+	if isUntyped(x.typ) {
+		return implicitType(x, T) != nil
+	}
+
+	return isNumeric(x.typ) && isNumeric(T)
+}
@@ -0,0 +1,408 @@
+package check
+
+import (
+	"go/constant"
+	"go/token"
+
+	"nano-go/ast"
+)
+
+// declInfo describes the declaration of a package-level object. The type of
+// the object is computed when it is first used or, at the latest, after all
+// objects were collected.
+type declInfo struct {
+	lhs   []*Var   // variables initialized together by a multi-valued init
+	typ   ast.Expr // type of a constant or a variable, nil if it has none
+	init  ast.Expr // value of a constant or a variable, nil if it has none
+	iota  int
+	fdecl *ast.FuncDecl
+	tdecl *ast.TypeSpec
+}
+
+func (c *Checker) file(file *ast.File) {
+	funcs := c.collectObjects(file)
+
+	for _, obj := range c.objList {
+		c.objDecl(obj)
+	}
+
+	if c.pkg.Lookup("main") == nil {
+		c.errorf(file, "function main is undeclared in the main package")
+	}
+
+	for _, decl := range funcs {
+		if obj, ok := c.info.Defs[decl.Name].(*Func); ok && decl.Body != nil {
+			c.funcBody(decl, obj.Type().(*Signature))
+		}
+	}
+}
+
+// collectObjects declares the package-level objects of file, their
+// declarations may refer to each other in any order. It returns the
+// functions whose bodies are to be checked.
+func (c *Checker) collectObjects(file *ast.File) []*ast.FuncDecl {
+	var funcs []*ast.FuncDecl
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil {
+				c.errorf(d, "methods are not implemented yet")
+				continue
+			}
+			obj := NewFunc(d.Name.Pos(), d.Name.Name, nil)
+			c.declarePkgObj(d.Name, obj, &declInfo{fdecl: d})
+			funcs = append(funcs, d)
+
+		case *ast.GenDecl:
+			switch d.Tok {
+			case token.IMPORT:
+				for _, spec := range d.Specs {
+					c.errorf(spec, "imports are not implemented yet")
+				}
+			case token.CONST:
+				var last *ast.ValueSpec
+				for _, spec := range d.Specs {
+					s := spec.(*ast.ValueSpec)
+					last = inheritSpec(s, last)
+					c.arityMatch(s, last)
+
+					for i, name := range s.Names {
+						obj := NewConst(name.Pos(), name.Name, nil, nil)
+						c.declarePkgObj(name, obj, &declInfo{typ: last.Type, init: valueAt(last, i), iota: s.Iota})
+					}
+				}
+			case token.VAR:
+				for _, spec := range d.Specs {
+					s := spec.(*ast.ValueSpec)
+					c.varArity(s)
+
+					lhs := make([]*Var, len(s.Names))
+					for i, name := range s.Names {
+						lhs[i] = NewVar(name.Pos(), name.Name, nil)
+					}
+
+					for i, name := range s.Names {
+						d := &declInfo{typ: s.Type, init: valueAt(s, i)}
+						if len(s.Values) == 1 && len(s.Names) > 1 {
+							d.lhs, d.init = lhs, s.Values[0]
+						}
+						c.declarePkgObj(name, lhs[i], d)
+					}
+				}
+			case token.TYPE:
+				for _, spec := range d.Specs {
+					s := spec.(*ast.TypeSpec)
+					obj := NewTypeName(s.Name.Pos(), s.Name.Name, nil)
+					c.declarePkgObj(s.Name, obj, &declInfo{tdecl: s})
+				}
+			default:
+				c.errorf(d, "%s declarations are not implemented yet", d.Tok)
+			}
+		}
+	}
+
+	return funcs
+}
+
+func (c *Checker) declarePkgObj(id *ast.Ident, obj Object, d *declInfo) {
+	c.declare(c.pkg, id, obj)
+	c.objMap[obj] = d
+	c.objList = append(c.objList, obj)
+}
+
+// objDecl computes the type of the package-level object obj if it was not
+// done yet.
+func (c *Checker) objDecl(obj Object) {
+	if c.resolving[obj] {
+		if obj, ok := obj.(*TypeName); ok {
+			// A declared type may refer to itself, typeDecl reports
+			// the cycles making its values infinite. An alias has no
+			// type until it is resolved, it cannot.
+			if obj.typ == nil {
+				c.diags.Errorf(obj.pos, "invalid recursive type: %s refers to itself", obj.name)
+				obj.typ = Typ[Invalid]
+			}
+			return
+		}
+		// The declaration being resolved gave obj an invalid type
+		// already, its uses inside the cycle are not reported again.
+		c.diags.Errorf(obj.Pos(), "initialization cycle: %s refers to itself", obj.Name())
+		return
+	}
+
+	d := c.objMap[obj]
+	if d == nil {
+		return
+	}
+	c.resolving[obj] = true
+
+	// obj may be used first in a function body or another declaration.
+	scope, iota := c.scope, c.iota
+	c.scope, c.iota = c.pkg, nil
+	defer func() {
+		c.scope, c.iota = scope, iota
+		delete(c.resolving, obj)
+		delete(c.objMap, obj)
+	}()
+
+	switch obj := obj.(type) {
+	case *Const:
+		c.constDecl(obj, d.typ, d.init, d.iota)
+	case *Var:
+		lhs := d.lhs
+		if lhs == nil {
+			lhs = []*Var{obj}
+		}
+		c.varDecl(lhs, d.typ, d.init, obj)
+		if d.init != nil {
+			c.info.InitOrder = append(c.info.InitOrder, &Initializer{lhs, d.init})
+		}
+		// The variables initialized together are resolved at once.
+		for _, v := range lhs {
+			delete(c.objMap, v)
+		}
+	case *Func:
+		c.funcDecl(obj, d.fdecl)
+	case *TypeName:
+		c.typeDecl(obj, d.tdecl)
+	}
+}
+
+// typeDecl computes the type of the type name obj. The values of a declared
+// type must not contain values of the type itself, they would be infinite.
+func (c *Checker) typeDecl(obj *TypeName, spec *ast.TypeSpec) {
+	if spec.Assign.IsValid() {
+		obj.typ = c.typExpr(spec.Type)
+		return
+	}
+
+	named := NewNamed(obj, nil)
+	named.underlying = c.typExpr(spec.Type)
+
+	path := cyclePath(named.underlying, named, make(map[*Named]bool))
+	if path == nil {
+		return
+	}
+	named.underlying = Typ[Invalid]
+
+	if len(path) == 1 {
+		c.errorf(spec.Name, "invalid recursive type: %s refers to itself", obj.name)
+		return
+	}
+	c.errorf(spec.Name, "invalid recursive type %s", obj.name)
+	from := named
+	for _, to := range path {
+		c.diags.Notef(from.obj.pos, "%s refers to %s", from, to)
+		from = to
+	}
+}
+
+// cyclePath returns the declared types leading from the values of t to the
+// ones of n, which end the path, nil if the values of t do not contain
+// values of n. The elements of slices and maps are not contained.
+func cyclePath(t Type, n *Named, seen map[*Named]bool) []*Named {
+	switch t := t.(type) {
+	case *Named:
+		if t == n {
+			return []*Named{n}
+		}
+		if seen[t] || t.underlying == nil {
+			return nil
+		}
+		seen[t] = true
+		if path := cyclePath(t.underlying, n, seen); path != nil {
+			return append([]*Named{t}, path...)
+		}
+	case *Array:
+		return cyclePath(t.elem, n, seen)
+	case *Struct:
+		for _, f := range t.fields {
+			if path := cyclePath(f.typ, n, seen); path != nil {
+				return path
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Checker) funcDecl(obj *Func, decl *ast.FuncDecl) {
+	sig := c.funcType(decl.Type)
+	obj.typ = sig
+
+	if decl.Name.Name == "main" && (sig.params.Len() > 0 || sig.results.Len() > 0) {
+		c.errorf(decl.Name, "func main must have no arguments and no return values")
+	}
+
+	if decl.Body == nil {
+		c.errorf(decl.Name, "missing function body")
+	}
+}
+
+// inheritSpec returns the spec a spec of a const declaration takes its type
+// and values from: the spec itself, or the previous one when it omits them.
+func inheritSpec(s, last *ast.ValueSpec) *ast.ValueSpec {
+	if s.Type != nil || len(s.Values) > 0 || last == nil {
+		return s
+	}
+	return last
+}
+
+func valueAt(s *ast.ValueSpec, i int) ast.Expr {
+	if i < len(s.Values) {
+		return s.Values[i]
+	}
+	return nil
+}
+
+// arityMatch checks that the spec s of a const declaration has a value for
+// every name, the values come from init.
+func (c *Checker) arityMatch(s, init *ast.ValueSpec) {
+	l, r := len(s.Names), len(init.Values)
+
+	switch {
+	case l > r:
+		c.errorf(s.Names[r], "missing init expr for const declaration")
+	case l < r && s == init:
+		c.errorf(s.Values[l], "extra init expr")
+	case l < r:
+		c.errorf(s, "extra init expr")
+	}
+}
+
+// varArity checks that the spec s of a var declaration has one value per
+// name or a single multi-valued one, or no values at all.
+func (c *Checker) varArity(s *ast.ValueSpec) {
+	l, r := len(s.Names), len(s.Values)
+	if r != 0 && r != 1 && l != r {
+		c.errorf(s, "assignment mismatch: %s but %s", plural(l, "variable"), plural(r, "value"))
+	}
+}
+
+// varDecl computes the types of the variables lhs declared together with
+// the type typ and the value init. More than one variable is initialized
+// by a multi-valued init.
+func (c *Checker) varDecl(lhs []*Var, typ, init ast.Expr, at Object) {
+	var t Type
+	if typ != nil {
+		t = c.typExpr(typ)
+	}
+
+	// The type of a variable is invalid while its init is checked, a use
+	// in its own init is an initialization cycle.
+	for _, v := range lhs {
+		v.typ = t
+		if t == nil {
+			v.typ = Typ[Invalid]
+		}
+	}
+
+	if init == nil {
+		if typ == nil {
+			c.diags.Errorf(at.Pos(), "missing type or init expr")
+		}
+		return
+	}
+
+	ops := c.unpack(len(lhs), []ast.Expr{init}, init)
+	if ops == nil {
+		return
+	}
+
+	for i, v := range lhs {
+		c.assignment(ops[i], t, "variable declaration")
+		if t == nil && ops[i].mode != invalid {
+			v.typ = ops[i].typ
+		}
+	}
+}
+
+// constDecl computes the type and the value of a constant.
+func (c *Checker) constDecl(obj *Const, typ, init ast.Expr, iota int) {
+	obj.typ = Typ[Invalid]
+	obj.val = constant.MakeUnknown()
+
+	c.iota = constant.MakeInt64(int64(iota))
+	defer func() {
+		c.iota = nil
+	}()
+
+	var t Type
+	if typ != nil {
+		t = c.typExpr(typ)
+		if t == Typ[Invalid] {
+			return
+		}
+		if !isConstType(t) {
+			c.errorf(typ, "invalid constant type %s", t)
+			return
+		}
+	}
+
+	if init == nil {
+		// Reported by arityMatch.
+		return
+	}
+
+	var x operand
+	c.expr(&x, init)
+	if x.mode == invalid {
+		return
+	}
+	if x.mode != constant_ {
+		c.errorf(x.expr, "%s is not constant", &x)
+		return
+	}
+
+	if t != nil {
+		c.assignment(&x, t, "constant declaration")
+		if x.mode == invalid {
+			return
+		}
+	}
+
+	obj.typ = x.typ
+	obj.val = x.val
+}
+
+func (c *Checker) funcBody(decl *ast.FuncDecl, sig *Signature) {
+	c.scope = NewScope(c.pkg)
+	c.sig = sig
+	defer func() {
+		c.scope = c.pkg
+		c.sig = nil
+	}()
+
+	c.declareParams(decl.Type.Params, sig.params)
+	c.declareParams(decl.Type.Results, sig.results)
+
+	c.stmtList(0, decl.Body.List)
+	c.usage(c.scope)
+	c.labels(decl.Body)
+
+	if sig.results.Len() > 0 && !isTerminating(decl.Body, "") {
+		c.diags.Errorf(decl.Body.Rbrace, "missing return")
+	}
+}
+
+// declareParams declares the named parameters or results of a function in
+// the function scope.
+func (c *Checker) declareParams(list *ast.FieldList, vars *Tuple) {
+	if list == nil {
+		return
+	}
+
+	i := 0
+	for _, field := range list.List {
+		if len(field.Names) == 0 {
+			i++
+			continue
+		}
+		for _, name := range field.Names {
+			// Parameters and results need not be used.
+			vars.At(i).used = true
+			c.declare(c.scope, name, vars.At(i))
+			i++
+		}
+	}
+}
@@ -0,0 +1,612 @@
+package check
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+
+	"nano-go/ast"
+)
+
+// rawExpr checks e and records its type. Unlike expr it accepts types,
+// builtins and calls with no or several results.
+func (c *Checker) rawExpr(x *operand, e ast.Expr) {
+	c.exprInternal(x, e)
+	x.expr = e
+
+	if x.mode != invalid {
+		c.record(x)
+	}
+}
+
+// expr checks that e is a single value.
+func (c *Checker) expr(x *operand, e ast.Expr) {
+	c.rawExpr(x, e)
+	c.singleValue(x)
+}
+
+func (c *Checker) singleValue(x *operand) {
+	switch x.mode {
+	case invalid:
+		return
+	case novalue:
+		c.errorf(x.expr, "%s (no value) used as value", ExprString(x.expr))
+	case builtin:
+		c.errorf(x.expr, "%s must be called", x)
+	case typexpr:
+		c.errorf(x.expr, "%s is not an expression", x)
+	default:
+		switch t := x.typ.(type) {
+		case *Tuple:
+			c.errorf(x.expr, "multiple-value %s (value of type %s) in single-value context", ExprString(x.expr), t)
+		case *Signature:
+			c.errorf(x.expr, "function values are not implemented yet")
+		default:
+			return
+		}
+	}
+
+	x.mode = invalid
+}
+
+// exprList checks a list of expressions that is used as a list of values.
+// A single call returning several results is unpacked into one operand per
+// result.
+func (c *Checker) exprList(list []ast.Expr) []*operand {
+	if len(list) == 1 {
+		x := new(operand)
+		c.rawExpr(x, list[0])
+
+		if t, ok := x.typ.(*Tuple); ok && x.mode != invalid {
+			ops := make([]*operand, t.Len())
+			for i := range ops {
+				ops[i] = &operand{mode: value, expr: x.expr, typ: t.At(i).typ}
+			}
+			return ops
+		}
+
+		c.singleValue(x)
+		return []*operand{x}
+	}
+
+	ops := make([]*operand, len(list))
+	for i, e := range list {
+		ops[i] = new(operand)
+		c.expr(ops[i], e)
+	}
+	return ops
+}
+
+func (c *Checker) exprInternal(x *operand, e ast.Expr) {
+	x.mode = invalid
+	x.typ = Typ[Invalid]
+
+	switch e := e.(type) {
+	case *ast.BadExpr:
+		// Reported by the parser.
+
+	case *ast.Ident:
+		c.ident(x, e)
+
+	case *ast.BasicLit:
+		c.basicLit(x, e)
+
+	case *ast.ParenExpr:
+		c.rawExpr(x, e.X)
+
+	case *ast.CallExpr:
+		c.call(x, e)
+
+	case *ast.UnaryExpr:
+		c.expr(x, e.X)
+		if x.mode != invalid {
+			c.unary(x, e)
+		}
+
+	case *ast.BinaryExpr:
+		c.binary(x, e)
+
+	case *ast.CompositeLit:
+		c.compositeLit(x, e, nil)
+
+	case *ast.IndexExpr:
+		c.indexExpr(x, e)
+
+	case *ast.SliceExpr:
+		c.sliceExpr(x, e)
+
+	case *ast.SelectorExpr:
+		c.selector(x, e)
+
+	case *ast.ArrayType, *ast.StructType, *ast.MapType, *ast.FuncType,
+		*ast.InterfaceType, *ast.ChanType:
+		x.typ = c.typExpr(e)
+		if x.typ != Typ[Invalid] {
+			x.mode = typexpr
+		}
+
+	default:
+		c.errorf(e, "%s are not implemented yet", exprKind(e))
+	}
+}
+
+// exprKind names the kind of an expression for diagnostics.
+func exprKind(e ast.Expr) string {
+	switch e.(type) {
+	case *ast.FuncLit:
+		return "function literals"
+	case *ast.TypeAssertExpr:
+		return "type assertions"
+	case *ast.StarExpr:
+		return "pointers"
+	}
+
+	return "expressions like " + ExprString(e)
+}
+
+func (c *Checker) ident(x *operand, e *ast.Ident) {
+	x.mode = invalid
+	x.expr = e
+	x.typ = Typ[Invalid]
+
+	if e.Name == "_" {
+		c.errorf(e, "cannot use _ as value")
+		return
+	}
+
+	obj := c.scope.LookupParent(e.Name)
+	if obj == nil {
+		c.errorf(e, "undefined: %s", e.Name)
+		return
+	}
+	c.info.Uses[e] = obj
+
+	c.objDecl(obj)
+
+	x.typ = obj.Type()
+	switch obj := obj.(type) {
+	case *Const:
+		if x.typ == Typ[Invalid] {
+			return
+		}
+		x.val = obj.val
+		if obj == universeIota {
+			if c.iota == nil {
+				c.errorf(e, "cannot use iota outside constant declaration")
+				return
+			}
+			x.val = c.iota
+		}
+		x.mode = constant_
+	case *Var:
+		obj.used = true
+		if x.typ == Typ[Invalid] {
+			// The declaration of the variable has an error.
+			return
+		}
+		x.mode = variable
+	case *Func:
+		x.mode = value
+	case *Nil:
+		x.mode = value
+	case *TypeName:
+		x.mode = typexpr
+	case *Builtin:
+		x.mode = builtin
+		x.id = obj.id
+	}
+}
+
+func (c *Checker) basicLit(x *operand, e *ast.BasicLit) {
+	switch e.Kind {
+	case token.INT:
+		x.typ = Typ[UntypedInt]
+	case token.CHAR:
+		x.typ = Typ[UntypedRune]
+	case token.STRING:
+		x.typ = Typ[UntypedString]
+	case token.FLOAT:
+		x.typ = Typ[UntypedFloat]
+	default:
+		c.errorf(e, "complex numbers are not supported")
+		return
+	}
+
+	x.val = constant.MakeFromLiteral(e.Value, e.Kind, 0)
+	if x.val.Kind() == constant.Unknown {
+		c.errorf(e, "malformed constant: %s", e.Value)
+		return
+	}
+
+	x.mode = constant_
+}
+
+func (c *Checker) unary(x *operand, e *ast.UnaryExpr) {
+	var ok bool
+	switch e.Op {
+	case token.ADD, token.SUB:
+		ok = isNumeric(x.typ)
+	case token.XOR:
+		ok = isInteger(x.typ)
+	case token.NOT:
+		ok = isBoolean(x.typ)
+	default:
+		c.errorf(e, "operator %s is not implemented yet", e.Op)
+		x.mode = invalid
+		return
+	}
+
+	if !ok {
+		c.errorf(e, "invalid operation: operator %s not defined on %s", e.Op, x)
+		x.mode = invalid
+		return
+	}
+
+	if x.mode == constant_ {
+		// The complement of an unsigned value has the bits of its type.
+		var prec uint
+		if isUnsigned(x.typ) && !isUntyped(x.typ) {
+			prec = uint(sizeof(x.typ) * 8)
+		}
+		x.val = constant.UnaryOp(e.Op, x.val, prec)
+		x.expr = e
+		c.overflow(x)
+		return
+	}
+
+	x.mode = value
+}
+
+func isShift(op token.Token) bool {
+	return op == token.SHL || op == token.SHR
+}
+
+func isComparison(op token.Token) bool {
+	switch op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		return true
+	}
+	return false
+}
+
+func (c *Checker) binary(x *operand, e *ast.BinaryExpr) {
+	var y operand
+
+	c.expr(x, e.X)
+	c.expr(&y, e.Y)
+	if x.mode == invalid {
+		return
+	}
+	if y.mode == invalid {
+		x.mode = invalid
+		return
+	}
+
+	if isShift(e.Op) {
+		c.shift(x, &y, e)
+		return
+	}
+
+	c.matchTypes(x, &y)
+	if x.mode == invalid || y.mode == invalid {
+		x.mode = invalid
+		return
+	}
+
+	if isComparison(e.Op) {
+		c.comparison(x, &y, e, false)
+		return
+	}
+
+	if !Identical(x.typ, y.typ) {
+		c.errorf(x.expr, "invalid operation: %s (mismatched types %s and %s)", ExprString(e), x.typ, y.typ)
+		x.mode = invalid
+		return
+	}
+
+	if !c.binaryOp(x, &y, e) {
+		x.mode = invalid
+		return
+	}
+
+	// Floating-point values may be divided by zero.
+	if (e.Op == token.QUO || e.Op == token.REM) && (x.mode == constant_ || isInteger(x.typ)) && y.mode == constant_ && constant.Sign(y.val) == 0 {
+		c.errorf(y.expr, "invalid operation: division by zero")
+		x.mode = invalid
+		return
+	}
+
+	if x.mode == constant_ && y.mode == constant_ {
+		x.val = foldBinary(x, &y, e.Op)
+		x.expr = e
+		c.overflow(x)
+		return
+	}
+
+	x.mode = value
+}
+
+// matchTypes converts an untyped operand of a binary operation to the type
+// of the other operand.
+func (c *Checker) matchTypes(x, y *operand) {
+	if isUntyped(x.typ) {
+		c.convertUntyped(x, y.typ)
+	}
+	if isUntyped(y.typ) && x.mode != invalid {
+		c.convertUntyped(y, x.typ)
+	}
+}
+
+// binaryOp checks that the operator of e is defined on the operands x and y
+// of an arithmetic operation.
+func (c *Checker) binaryOp(x, y *operand, e *ast.BinaryExpr) bool {
+	var ok bool
+	switch e.Op {
+	case token.ADD:
+		if isString(x.typ) {
+			if x.mode != constant_ || y.mode != constant_ {
+				c.errorf(e, "string concatenation is not implemented yet")
+				return false
+			}
+			return true
+		}
+		ok = isNumeric(x.typ)
+	case token.SUB, token.MUL, token.QUO:
+		ok = isNumeric(x.typ)
+	case token.REM, token.AND, token.OR, token.XOR, token.AND_NOT:
+		ok = isInteger(x.typ)
+	case token.LAND, token.LOR:
+		ok = isBoolean(x.typ)
+	default:
+		c.errorf(e, "operator %s is not implemented yet", e.Op)
+		return false
+	}
+
+	if !ok {
+		c.errorf(x.expr, "invalid operation: operator %s not defined on %s", e.Op, x)
+	}
+	return ok
+}
+
+// isNil reports whether e is the predeclared nil.
+func (c *Checker) isNil(e ast.Expr) bool {
+	id, ok := unparen(e).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, ok = c.info.Uses[id].(*Nil)
+	return ok
+}
+
+// comparison checks the comparison e of x with y. A switch case x compared
+// with the tag y has its errors reported at the case.
+func (c *Checker) comparison(x, y *operand, e *ast.BinaryExpr, switchCase bool) {
+	// The error is reported at the operand it is about, a mismatch is
+	// only found at the second one.
+	var errOp *operand
+	cause := ""
+	switch {
+	case !assignableTo(x, y.typ) && !assignableTo(y, x.typ):
+		errOp = y
+		cause = fmt.Sprintf("mismatched types %s and %s", x.typ, y.typ)
+	case e.Op == token.EQL || e.Op == token.NEQ:
+		switch {
+		case c.isNil(x.expr) || c.isNil(y.expr):
+			// Slices and maps can be compared to nil, nil to nothing.
+			typ := x.typ
+			if c.isNil(x.expr) {
+				typ = y.typ
+			}
+			if !hasNil(typ) {
+				errOp = y
+			}
+		case !comparable(x.typ):
+			errOp = x
+			cause = incomparableCause(x.typ)
+		case !comparable(y.typ):
+			errOp = y
+			cause = incomparableCause(y.typ)
+		}
+	default:
+		switch {
+		case !isOrdered(x.typ):
+			errOp = x
+		case !isOrdered(y.typ):
+			errOp = y
+		}
+	}
+
+	if errOp != nil {
+		if cause == "" {
+			cause = fmt.Sprintf("operator %s not defined on %s", e.Op, kindString(errOp.typ))
+		}
+		if switchCase {
+			c.errorf(x.expr, "invalid case %s in switch on %s (%s)", ExprString(x.expr), ExprString(y.expr), cause)
+		} else {
+			c.errorf(errOp.expr, "invalid operation: %s (%s)", ExprString(e), cause)
+		}
+		x.mode = invalid
+		return
+	}
+
+	if x.mode == constant_ && y.mode == constant_ {
+		x.val = constant.MakeBool(constant.Compare(x.val, e.Op, y.val))
+		x.typ = Typ[UntypedBool]
+		return
+	}
+
+	if isString(y.typ) && e.Op != token.EQL && e.Op != token.NEQ {
+		c.errorf(x.expr, "comparison of strings is not implemented yet")
+		x.mode = invalid
+		return
+	}
+
+	// The operands are computed, the untyped ones with their default
+	// types.
+	c.updateExprType(x.expr, Default(x.typ))
+	c.updateExprType(y.expr, Default(y.typ))
+
+	x.mode = value
+	x.typ = Typ[UntypedBool]
+}
+
+func (c *Checker) shift(x, y *operand, e *ast.BinaryExpr) {
+	// An untyped constant operand only has to have an integer value.
+	intConst := false
+	if x.mode == constant_ && isUntyped(x.typ) {
+		if val := constant.ToInt(x.val); val.Kind() == constant.Int {
+			x.val = val
+			intConst = true
+		}
+	}
+
+	if !isInteger(x.typ) && !intConst {
+		c.errorf(x.expr, "invalid operation: shifted operand %s must be integer", x)
+		x.mode = invalid
+		return
+	}
+
+	var count uint64
+	if y.mode == constant_ {
+		val := constant.ToInt(y.val)
+		if val.Kind() != constant.Int || constant.Sign(val) < 0 {
+			c.errorf(y.expr, "invalid operation: invalid shift count %s", y)
+			x.mode = invalid
+			return
+		}
+
+		var ok bool
+		if count, ok = constant.Uint64Val(val); x.mode == constant_ && (!ok || count > shiftBound) {
+			c.errorf(y.expr, "invalid operation: invalid shift count %s (too large)", y)
+			x.mode = invalid
+			return
+		}
+		y.val = val
+	}
+
+	if isUntyped(y.typ) && !c.convertUntyped(y, Typ[Uint]) {
+		c.errorf(y.expr, "invalid operation: shift count %s must be integer", y)
+		x.mode = invalid
+		return
+	}
+	if y.mode == invalid {
+		x.mode = invalid
+		return
+	}
+
+	if !isInteger(y.typ) {
+		c.errorf(y.expr, "invalid operation: shift count %s must be integer", y)
+		x.mode = invalid
+		return
+	}
+
+	if x.mode == constant_ {
+		if y.mode == constant_ {
+			// A constant shift of an untyped constant is an integer.
+			if isUntyped(x.typ) {
+				x.typ = Typ[UntypedInt]
+			}
+			x.val = constant.Shift(x.val, e.Op, uint(count))
+			x.expr = e
+			c.overflow(x)
+			return
+		}
+
+		// The untyped operand of a non-constant shift has the type the
+		// shift gets from its context, updateExprType checks it then.
+		if isUntyped(x.typ) {
+			c.shiftOperands[x.expr] = true
+		}
+	}
+
+	x.mode = value
+}
+
+func (c *Checker) call(x *operand, call *ast.CallExpr) {
+	c.rawExpr(x, call.Fun)
+
+	switch x.mode {
+	case invalid:
+		c.exprList(call.Args)
+		return
+	case typexpr:
+		c.conversion(x, call, x.typ)
+		return
+	case builtin:
+		c.builtin(x, call, x.id)
+		return
+	}
+
+	sig, ok := x.typ.Underlying().(*Signature)
+	if !ok {
+		c.errorf(call, "invalid operation: cannot call non-function %s", x)
+		c.use(call.Args...)
+		x.mode = invalid
+		return
+	}
+
+	if call.Ellipsis && !sig.variadic {
+		c.errorf(call, "cannot use ... in call to non-variadic %s", ExprString(call.Fun))
+	}
+
+	args := c.exprList(call.Args)
+	c.arguments(call, sig, args)
+
+	switch sig.results.Len() {
+	case 0:
+		x.mode = novalue
+	case 1:
+		x.mode = value
+		x.typ = sig.results.At(0).typ
+	default:
+		x.mode = value
+		x.typ = sig.results
+	}
+}
+
+// arguments checks the arguments of a call of a function with the signature
+// sig. The arguments of a variadic parameter have its element type, unless
+// the call passes the slice itself with f(xs...).
+func (c *Checker) arguments(call *ast.CallExpr, sig *Signature, args []*operand) {
+	for _, arg := range args {
+		if arg.mode == invalid {
+			return
+		}
+	}
+
+	params := sig.params
+	want := make([]Type, params.Len())
+	for i := range want {
+		want[i] = params.At(i).typ
+	}
+
+	if sig.variadic && !call.Ellipsis {
+		elem := want[len(want)-1].(*Slice).elem
+		want = want[:len(want)-1]
+		for len(want) < len(args) {
+			want = append(want, elem)
+		}
+	}
+
+	if len(args) != len(want) {
+		qualifier := "not enough"
+		if len(args) > len(want) {
+			qualifier = "too many"
+		}
+		c.errorf(call, "%s arguments in call to %s\n\thave %s\n\twant %s",
+			qualifier, ExprString(call.Fun), operandTypes(args), params.paramsString(sig.variadic))
+		return
+	}
+
+	for i, arg := range args {
+		c.assignment(arg, want[i], "argument to "+ExprString(call.Fun))
+	}
+}
+
+func operandTypes(list []*operand) string {
+	vars := make([]*Var, len(list))
+	for i, x := range list {
+		vars[i] = NewVar(noPos, "", x.typ)
+	}
+	return NewTuple(vars...).signatureString()
+}
@@ -0,0 +1,197 @@
+package check
+
+import (
+	"bytes"
+
+	"nano-go/ast"
+)
+
+// ExprString returns the source form of x for diagnostics. Function and
+// composite literal bodies are shortened.
+func ExprString(x ast.Expr) string {
+	var buf bytes.Buffer
+	writeExpr(&buf, x)
+	return buf.String()
+}
+
+func writeExpr(buf *bytes.Buffer, x ast.Expr) {
+	switch x := x.(type) {
+	case *ast.BadExpr:
+		buf.WriteString("BadExpr")
+
+	case *ast.Ident:
+		buf.WriteString(x.Name)
+
+	case *ast.BasicLit:
+		buf.WriteString(x.Value)
+
+	case *ast.FuncLit:
+		buf.WriteByte('(')
+		writeExpr(buf, x.Type)
+		buf.WriteString(" literal)")
+
+	case *ast.CompositeLit:
+		if x.Type != nil {
+			writeExpr(buf, x.Type)
+		}
+		if len(x.Elts) > 0 {
+			buf.WriteString("{…}")
+		} else {
+			buf.WriteString("{}")
+		}
+
+	case *ast.ParenExpr:
+		buf.WriteByte('(')
+		writeExpr(buf, x.X)
+		buf.WriteByte(')')
+
+	case *ast.SelectorExpr:
+		writeExpr(buf, x.X)
+		buf.WriteByte('.')
+		buf.WriteString(x.Sel.Name)
+
+	case *ast.IndexExpr:
+		writeExpr(buf, x.X)
+		buf.WriteByte('[')
+		writeExpr(buf, x.Index)
+		buf.WriteByte(']')
+
+	case *ast.SliceExpr:
+		writeExpr(buf, x.X)
+		buf.WriteByte('[')
+		if x.Low != nil {
+			writeExpr(buf, x.Low)
+		}
+		buf.WriteByte(':')
+		if x.High != nil {
+			writeExpr(buf, x.High)
+		}
+		if x.Slice3 {
+			buf.WriteByte(':')
+			if x.Max != nil {
+				writeExpr(buf, x.Max)
+			}
+		}
+		buf.WriteByte(']')
+
+	case *ast.TypeAssertExpr:
+		writeExpr(buf, x.X)
+		buf.WriteString(".(")
+		writeExpr(buf, x.Type)
+		buf.WriteByte(')')
+
+	case *ast.CallExpr:
+		writeExpr(buf, x.Fun)
+		buf.WriteByte('(')
+		writeExprList(buf, x.Args)
+		if x.Ellipsis {
+			buf.WriteString("...")
+		}
+		buf.WriteByte(')')
+
+	case *ast.StarExpr:
+		buf.WriteByte('*')
+		writeExpr(buf, x.X)
+
+	case *ast.UnaryExpr:
+		buf.WriteString(x.Op.String())
+		writeExpr(buf, x.X)
+
+	case *ast.BinaryExpr:
+		writeExpr(buf, x.X)
+		buf.WriteString(" " + x.Op.String() + " ")
+		writeExpr(buf, x.Y)
+
+	case *ast.KeyValueExpr:
+		writeExpr(buf, x.Key)
+		buf.WriteString(": ")
+		writeExpr(buf, x.Value)
+
+	case *ast.ArrayType:
+		buf.WriteByte('[')
+		if x.Len != nil {
+			writeExpr(buf, x.Len)
+		}
+		buf.WriteByte(']')
+		writeExpr(buf, x.Elt)
+
+	case *ast.Ellipsis:
+		buf.WriteString("...")
+		if x.Elt != nil {
+			writeExpr(buf, x.Elt)
+		}
+
+	case *ast.StructType:
+		buf.WriteString("struct{")
+		writeFieldList(buf, x.Fields, "; ")
+		buf.WriteByte('}')
+
+	case *ast.FuncType:
+		buf.WriteString("func(")
+		writeFieldList(buf, x.Params, ", ")
+		buf.WriteByte(')')
+		if n := x.Results.NumFields(); n > 0 {
+			buf.WriteByte(' ')
+			if n == 1 && len(x.Results.List[0].Names) == 0 {
+				writeExpr(buf, x.Results.List[0].Type)
+			} else {
+				buf.WriteByte('(')
+				writeFieldList(buf, x.Results, ", ")
+				buf.WriteByte(')')
+			}
+		}
+
+	case *ast.InterfaceType:
+		buf.WriteString("interface{")
+		writeFieldList(buf, x.Methods, "; ")
+		buf.WriteByte('}')
+
+	case *ast.MapType:
+		buf.WriteString("map[")
+		writeExpr(buf, x.Key)
+		buf.WriteByte(']')
+		writeExpr(buf, x.Value)
+
+	case *ast.ChanType:
+		switch x.Dir {
+		case ast.SEND:
+			buf.WriteString("chan<- ")
+		case ast.RECV:
+			buf.WriteString("<-chan ")
+		default:
+			buf.WriteString("chan ")
+		}
+		writeExpr(buf, x.Value)
+	}
+}
+
+func writeExprList(buf *bytes.Buffer, list []ast.Expr) {
+	for i, x := range list {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		writeExpr(buf, x)
+	}
+}
+
+func writeFieldList(buf *bytes.Buffer, list *ast.FieldList, sep string) {
+	if list == nil {
+		return
+	}
+
+	for i, f := range list.List {
+		if i > 0 {
+			buf.WriteString(sep)
+		}
+		for i, name := range f.Names {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(name.Name)
+		}
+		if len(f.Names) > 0 {
+			buf.WriteByte(' ')
+		}
+		writeExpr(buf, f.Type)
+	}
+}
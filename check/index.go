@@ -0,0 +1,235 @@
+package check
+
+import (
+	"go/constant"
+
+	"nano-go/ast"
+)
+
+// indexExpr checks the index expression e. Indexing a slice or an
+// addressable array gives a variable, indexing a map a map index.
+func (c *Checker) indexExpr(x *operand, e *ast.IndexExpr) {
+	c.expr(x, e.X)
+	if x.mode == invalid {
+		c.use(e.Index)
+		return
+	}
+
+	valid := false
+	length := int64(-1)
+	switch typ := x.typ.Underlying().(type) {
+	case *Basic:
+		if typ.is(IsString) {
+			valid = true
+			if x.mode == constant_ {
+				length = int64(len(constant.StringVal(x.val)))
+			}
+			// The bytes of a string cannot be assigned.
+			x.mode = value
+			x.typ = Typ[Byte]
+		}
+
+	case *Array:
+		valid = true
+		length = typ.len
+		if x.mode != variable {
+			x.mode = value
+		}
+		x.typ = typ.elem
+
+	case *Slice:
+		valid = true
+		x.mode = variable
+		x.typ = typ.elem
+
+	case *Map:
+		var key operand
+		c.expr(&key, e.Index)
+		c.assignment(&key, typ.key, "map index")
+		x.mode = mapindex
+		x.typ = typ.elem
+		return
+	}
+
+	if !valid {
+		c.errorf(x.expr, "invalid operation: cannot index %s", x)
+		c.use(e.Index)
+		x.mode = invalid
+		return
+	}
+
+	c.index(e.Index, length)
+}
+
+// sliceExpr checks the slice expression e of a string, an addressable array
+// or a slice.
+func (c *Checker) sliceExpr(x *operand, e *ast.SliceExpr) {
+	c.expr(x, e.X)
+	if x.mode == invalid {
+		c.use(e.Low, e.High, e.Max)
+		return
+	}
+
+	valid := false
+	length := int64(-1)
+	switch typ := x.typ.Underlying().(type) {
+	case *Basic:
+		if typ.is(IsString) {
+			if e.Slice3 {
+				at := e.Max
+				if at == nil {
+					at = e
+				}
+				c.errorf(at, "invalid operation: 3-index slice of string")
+				c.use(e.Low, e.High, e.Max)
+				x.mode = invalid
+				return
+			}
+			valid = true
+			if x.mode == constant_ {
+				length = int64(len(constant.StringVal(x.val)))
+			}
+			// Slicing an untyped string gives a string value.
+			if isUntyped(x.typ) {
+				x.typ = Typ[String]
+			}
+		}
+
+	case *Array:
+		valid = true
+		length = typ.len
+		if x.mode != variable {
+			c.errorf(x.expr, "cannot slice unaddressable value %s", x)
+			c.use(e.Low, e.High, e.Max)
+			x.mode = invalid
+			return
+		}
+		x.typ = NewSlice(typ.elem)
+
+	case *Slice:
+		valid = true
+	}
+
+	if !valid {
+		c.errorf(x.expr, "cannot slice %s", x)
+		c.use(e.Low, e.High, e.Max)
+		x.mode = invalid
+		return
+	}
+
+	x.mode = value
+
+	// Only the first index may be omitted, it is 0 then.
+	if e.Slice3 && (e.High == nil || e.Max == nil) {
+		c.errorf(e, "2nd and 3rd index required in 3-index slice")
+		c.use(e.Low, e.High, e.Max)
+		x.mode = invalid
+		return
+	}
+
+	// The constant indices, or the defaults, are compared when known. The
+	// capacity is only known for strings and arrays, it is their length.
+	exprs := []ast.Expr{e.Low, e.High, e.Max}
+	var ind [3]int64
+	for i, expr := range exprs {
+		v := int64(-1)
+		switch {
+		case expr != nil:
+			max := int64(-1)
+			if length >= 0 {
+				max = length + 1
+			}
+			if _, val := c.index(expr, max); val >= 0 {
+				v = val
+			}
+		case i == 0:
+			v = 0
+		case length >= 0:
+			v = length
+		}
+		ind[i] = v
+	}
+
+L:
+	for i, lo := range ind[:len(ind)-1] {
+		if lo > 0 {
+			for j, hi := range ind[i+1:] {
+				if hi >= 0 && hi < lo {
+					// The default of an index is not reported, the
+					// indices after it are.
+					at := exprs[i+1+j]
+					if at == nil {
+						at = e
+					}
+					c.errorf(at, "invalid slice indices: %d < %d", hi, lo)
+					break L
+				}
+			}
+		}
+	}
+}
+
+// index checks the index e of a value of length max, -1 if it is unknown.
+// It returns the type of the index and its value when it is constant, -1
+// otherwise. The type is Typ[Invalid] after an error.
+func (c *Checker) index(e ast.Expr, max int64) (typ Type, val int64) {
+	var x operand
+	c.expr(&x, e)
+	if !c.isValidIndex(&x, "index", false) {
+		return Typ[Invalid], -1
+	}
+
+	if x.mode != constant_ {
+		return x.typ, -1
+	}
+
+	v, _ := constant.Int64Val(x.val)
+	if max >= 0 && v >= max {
+		c.errorf(e, "invalid argument: index %s out of bounds [0:%d]", x.val, max)
+		return Typ[Invalid], -1
+	}
+	return x.typ, v
+}
+
+// isValidIndex checks that x can be an index, what names it in errors.
+// Untyped constants get the type int.
+func (c *Checker) isValidIndex(x *operand, what string, allowNegative bool) bool {
+	if x.mode == invalid {
+		return false
+	}
+
+	if isUntyped(x.typ) {
+		if !c.convertUntyped(x, Typ[Int]) {
+			c.errorf(x.expr, "invalid argument: %s %s must be integer", what, x)
+			return false
+		}
+		if x.mode == invalid {
+			return false
+		}
+	}
+
+	if !isInteger(x.typ) {
+		c.errorf(x.expr, "invalid argument: %s %s must be integer", what, x)
+		return false
+	}
+
+	if x.mode == constant_ && !allowNegative && constant.Sign(x.val) < 0 {
+		c.errorf(x.expr, "invalid argument: %s %s must not be negative", what, x)
+		return false
+	}
+
+	return true
+}
+
+// use checks the expressions that cannot be used after an error, so that
+// their errors are reported and their variables are used. Missing
+// expressions are nil.
+func (c *Checker) use(list ...ast.Expr) {
+	for _, e := range list {
+		if e == nil {
+			continue
+		}
+		var x operand
+		c.rawExpr(&x, e)
+	}
+}
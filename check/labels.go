@@ -0,0 +1,221 @@
+package check
+
+import (
+	"go/token"
+
+	"nano-go/ast"
+	"nano-go/diag"
+)
+
+// labels checks the labels of a function body: labels must be used, and the
+// labels of break, continue and goto statements must be valid targets.
+func (c *Checker) labels(body *ast.BlockStmt) {
+	all := NewScope(nil)
+
+	fwdJumps := c.blockBranches(all, nil, nil, body.List)
+
+	// The remaining forward jumps found no label in an enclosing block.
+	for _, jmp := range fwdJumps {
+		name := jmp.Label.Name
+		if alt := all.Lookup(name); alt != nil {
+			alt.(*Label).used = true
+			c.errorf(jmp.Label, "goto %s jumps into block", name)
+		} else {
+			c.errorf(jmp.Label, "label %s not defined", name)
+		}
+	}
+
+	for _, obj := range all.elems {
+		if lbl := obj.(*Label); !lbl.used {
+			c.diags.Errorf(lbl.pos, "label %s declared and not used", lbl.name)
+		}
+	}
+}
+
+// block is a block of statements for the label checks.
+type block struct {
+	parent *block
+	// lstmt is the labeled statement the block belongs to, if any.
+	lstmt  *ast.LabeledStmt
+	labels map[string]*ast.LabeledStmt
+}
+
+func (b *block) insert(s *ast.LabeledStmt) {
+	if b.labels == nil {
+		b.labels = make(map[string]*ast.LabeledStmt)
+	}
+	b.labels[s.Label.Name] = s
+}
+
+// gotoTarget returns the labeled statement a goto name in b may jump to,
+// nil if there is none.
+func (b *block) gotoTarget(name string) *ast.LabeledStmt {
+	for s := b; s != nil; s = s.parent {
+		if t := s.labels[name]; t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// enclosingTarget returns the innermost labeled statement with the given
+// name that encloses b, nil if there is none.
+func (b *block) enclosingTarget(name string) *ast.LabeledStmt {
+	for s := b; s != nil; s = s.parent {
+		if t := s.lstmt; t != nil && t.Label.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// blockBranches checks the labels and branch statements of the statement
+// list of a block, lstmt is the labeled statement the block belongs to. It
+// returns the goto statements jumping forward to labels that are not
+// declared in the block.
+func (c *Checker) blockBranches(all *Scope, parent *block, lstmt *ast.LabeledStmt, list []ast.Stmt) []*ast.BranchStmt {
+	b := &block{parent: parent, lstmt: lstmt}
+
+	var (
+		varDeclPos         diag.Position
+		fwdJumps, badJumps []*ast.BranchStmt
+	)
+
+	// The forward jumps pending at a variable declaration jump over it if
+	// their label follows.
+	recordVarDecl := func(pos diag.Position) {
+		varDeclPos = pos
+		badJumps = append(badJumps[:0], fwdJumps...)
+	}
+
+	jumpsOverVarDecl := func(jmp *ast.BranchStmt) bool {
+		if varDeclPos.IsValid() {
+			for _, bad := range badJumps {
+				if jmp == bad {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	blockBranches := func(lstmt *ast.LabeledStmt, list []ast.Stmt) {
+		fwdJumps = append(fwdJumps, c.blockBranches(all, b, lstmt, list)...)
+	}
+
+	var stmtBranches func(lstmt *ast.LabeledStmt, s ast.Stmt)
+	stmtBranches = func(lstmt *ast.LabeledStmt, s ast.Stmt) {
+		switch s := s.(type) {
+		case *ast.DeclStmt:
+			if d, ok := s.Decl.(*ast.GenDecl); ok && d.Tok == token.VAR {
+				recordVarDecl(d.Pos())
+			}
+
+		case *ast.LabeledStmt:
+			if name := s.Label.Name; name != "_" {
+				lbl := NewLabel(s.Label.Pos(), name)
+				if alt := all.Insert(lbl); alt != nil {
+					c.errorf(s.Label, "label %s already declared", name)
+					c.diags.Notef(alt.Pos(), "other declaration of %s", name)
+				} else {
+					b.insert(s)
+					c.info.Defs[s.Label] = lbl
+				}
+
+				// Resolve the forward jumps to the label.
+				i := 0
+				for _, jmp := range fwdJumps {
+					if jmp.Label.Name != name {
+						fwdJumps[i] = jmp
+						i++
+						continue
+					}
+
+					lbl.used = true
+					c.info.Uses[jmp.Label] = lbl
+					if jumpsOverVarDecl(jmp) {
+						c.errorf(jmp.Label, "goto %s jumps over variable declaration at line %d", name, varDeclPos.Line)
+					}
+				}
+				fwdJumps = fwdJumps[:i]
+			}
+			stmtBranches(s, s.Stmt)
+
+		case *ast.BranchStmt:
+			if s.Label == nil {
+				return
+			}
+
+			name := s.Label.Name
+			switch s.Tok {
+			case token.BREAK:
+				valid := false
+				if t := b.enclosingTarget(name); t != nil {
+					switch t.Stmt.(type) {
+					case *ast.SwitchStmt, *ast.ForStmt, *ast.RangeStmt:
+						valid = true
+					}
+				}
+				if !valid {
+					c.errorf(s.Label, "invalid break label %s", name)
+					return
+				}
+
+			case token.CONTINUE:
+				valid := false
+				if t := b.enclosingTarget(name); t != nil {
+					switch t.Stmt.(type) {
+					case *ast.ForStmt, *ast.RangeStmt:
+						valid = true
+					}
+				}
+				if !valid {
+					c.errorf(s.Label, "invalid continue label %s", name)
+					return
+				}
+
+			case token.GOTO:
+				if b.gotoTarget(name) == nil {
+					// The label may follow the goto.
+					fwdJumps = append(fwdJumps, s)
+					return
+				}
+			}
+
+			obj := all.Lookup(name)
+			obj.(*Label).used = true
+			c.info.Uses[s.Label] = obj
+
+		case *ast.AssignStmt:
+			if s.Tok == token.DEFINE {
+				recordVarDecl(s.Pos())
+			}
+
+		case *ast.BlockStmt:
+			blockBranches(lstmt, s.List)
+
+		case *ast.IfStmt:
+			stmtBranches(nil, s.Body)
+			if s.Else != nil {
+				stmtBranches(nil, s.Else)
+			}
+
+		case *ast.SwitchStmt:
+			for _, clause := range s.Body.List {
+				blockBranches(lstmt, clause.(*ast.CaseClause).Body)
+			}
+
+		case *ast.ForStmt:
+			stmtBranches(lstmt, s.Body)
+
+		case *ast.RangeStmt:
+			stmtBranches(lstmt, s.Body)
+		}
+	}
+
+	for _, s := range list {
+		stmtBranches(nil, s)
+	}
+
+	return fwdJumps
+}
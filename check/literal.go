@@ -0,0 +1,210 @@
+package check
+
+import "nano-go/ast"
+
+// exprWithHint checks e like expr. A composite literal without a type, an
+// element of an enclosing literal, gets the type hint.
+func (c *Checker) exprWithHint(x *operand, e ast.Expr, hint Type) {
+	lit, ok := e.(*ast.CompositeLit)
+	if !ok || lit.Type != nil {
+		c.expr(x, e)
+		return
+	}
+
+	c.compositeLit(x, lit, hint)
+	x.expr = e
+	if x.mode != invalid {
+		c.record(x)
+	}
+}
+
+func (c *Checker) compositeLit(x *operand, e *ast.CompositeLit, hint Type) {
+	x.mode = invalid
+
+	var typ Type
+	switch {
+	case e.Type != nil:
+		// The length of [...]T is the one of the literal.
+		if atyp, ok := e.Type.(*ast.ArrayType); ok && atyp.Len != nil {
+			if _, ok := atyp.Len.(*ast.Ellipsis); ok {
+				elem := c.typExpr(atyp.Elt)
+				n := c.indexedElts(e.Elts, elem, -1)
+				if elem != Typ[Invalid] {
+					x.mode = value
+					x.typ = NewArray(elem, n)
+				}
+				return
+			}
+		}
+		typ = c.typExpr(e.Type)
+
+	case hint != nil:
+		typ = hint
+
+	default:
+		c.errorf(e, "missing type in composite literal")
+		return
+	}
+
+	switch utyp := typ.Underlying().(type) {
+	case *Array:
+		c.indexedElts(e.Elts, utyp.elem, utyp.len)
+
+	case *Slice:
+		c.indexedElts(e.Elts, utyp.elem, -1)
+
+	case *Map:
+		c.mapElts(e.Elts, utyp)
+
+	case *Struct:
+		c.structElts(e, utyp, typ)
+
+	default:
+		if typ != Typ[Invalid] {
+			c.errorf(e, "invalid composite literal type %s", typ)
+		}
+		return
+	}
+
+	x.mode = value
+	x.typ = typ
+}
+
+// indexedElts checks the elements of an array or slice literal with
+// elements of type typ and length length, -1 for [...]T and slices. It
+// returns the length the elements need.
+func (c *Checker) indexedElts(elts []ast.Expr, typ Type, length int64) int64 {
+	visited := make(map[int64]bool, len(elts))
+
+	var index, max int64
+	for _, e := range elts {
+		validIndex := false
+		eval := e
+		if kv, ok := e.(*ast.KeyValueExpr); ok {
+			if t, i := c.index(kv.Key, length); t != Typ[Invalid] {
+				if i >= 0 {
+					index = i
+					validIndex = true
+				} else {
+					c.errorf(e, "index %s must be integer constant", ExprString(kv.Key))
+				}
+			}
+			eval = kv.Value
+		} else if length >= 0 && index >= length {
+			c.errorf(e, "index %d is out of bounds (>= %d)", index, length)
+		} else {
+			validIndex = true
+		}
+
+		if validIndex {
+			if visited[index] {
+				c.errorf(e, "duplicate index %d in array or slice literal", index)
+			} else {
+				visited[index] = true
+			}
+		}
+
+		index++
+		if index > max {
+			max = index
+		}
+
+		var x operand
+		c.exprWithHint(&x, eval, typ)
+		if typ != Typ[Invalid] {
+			c.assignment(&x, typ, "array or slice literal")
+		}
+	}
+
+	return max
+}
+
+// mapElts checks the elements of a map literal of type typ. Constant keys
+// must be unique.
+func (c *Checker) mapElts(elts []ast.Expr, typ *Map) {
+	visited := make(map[string]bool, len(elts))
+
+	for _, e := range elts {
+		kv, ok := e.(*ast.KeyValueExpr)
+		if !ok {
+			c.errorf(e, "missing key in map literal")
+			c.use(e)
+			continue
+		}
+
+		var x operand
+		c.exprWithHint(&x, kv.Key, typ.key)
+		c.assignment(&x, typ.key, "map literal")
+		if x.mode == constant_ {
+			if key := x.val.ExactString(); visited[key] {
+				c.errorf(x.expr, "duplicate key %s in map literal", x.val)
+			} else {
+				visited[key] = true
+			}
+		}
+
+		c.exprWithHint(&x, kv.Value, typ.elem)
+		c.assignment(&x, typ.elem, "map literal")
+	}
+}
+
+// structElts checks the elements of the literal e of the struct type typ.
+// Either all elements are field:value pairs, or there is a value for every
+// field in order.
+func (c *Checker) structElts(e *ast.CompositeLit, utyp *Struct, typ Type) {
+	if len(e.Elts) == 0 {
+		return
+	}
+
+	var x operand
+	if _, ok := e.Elts[0].(*ast.KeyValueExpr); ok {
+		visited := make(map[int]bool, len(e.Elts))
+		for _, elt := range e.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				c.errorf(elt, "mixture of field:value and value elements in struct literal")
+				c.use(elt)
+				continue
+			}
+
+			c.expr(&x, kv.Value)
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				c.errorf(kv, "invalid field name %s in struct literal", ExprString(kv.Key))
+				continue
+			}
+			i := utyp.fieldIndex(key.Name, false)
+			if i < 0 {
+				c.errorf(key, "%s", lookupError(typ, utyp, key.Name, true))
+				continue
+			}
+
+			c.info.Uses[key] = utyp.fields[i]
+			c.assignment(&x, utyp.fields[i].typ, "struct literal")
+			if visited[i] {
+				c.errorf(kv, "duplicate field name %s in struct literal", key.Name)
+			}
+			visited[i] = true
+		}
+		return
+	}
+
+	for i, elt := range e.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			c.errorf(elt, "mixture of field:value and value elements in struct literal")
+			c.use(kv.Value)
+			continue
+		}
+
+		c.expr(&x, elt)
+		if i >= len(utyp.fields) {
+			c.errorf(elt, "too many values in struct literal of type %s", typ)
+			break
+		}
+		c.assignment(&x, utyp.fields[i].typ, "struct literal")
+	}
+
+	if len(e.Elts) < len(utyp.fields) {
+		c.diags.Errorf(e.Rbrace, "too few values in struct literal of type %s", typ)
+	}
+}
@@ -0,0 +1,114 @@
+package check
+
+import (
+	"go/constant"
+
+	"nano-go/diag"
+)
+
+// Object is a named language entity: a constant, a variable, a function, a
+// type name, a label or a builtin function.
+type Object interface {
+	Name() string
+	Type() Type
+	// Pos is the position of the declaration, invalid for predeclared
+	// objects.
+	Pos() diag.Position
+	// Parent is the scope the object is declared in.
+	Parent() *Scope
+
+	setParent(*Scope)
+}
+
+type object struct {
+	parent *Scope
+	pos    diag.Position
+	name   string
+	typ    Type
+}
+
+func (obj *object) Name() string           { return obj.name }
+func (obj *object) Type() Type             { return obj.typ }
+func (obj *object) Pos() diag.Position     { return obj.pos }
+func (obj *object) Parent() *Scope         { return obj.parent }
+func (obj *object) setParent(scope *Scope) { obj.parent = scope }
+
+// Const is a declared constant, its value has the representation of its
+// type. The value of iota depends on the declaration it is used in.
+type Const struct {
+	object
+	val constant.Value
+}
+
+func NewConst(pos diag.Position, name string, typ Type, val constant.Value) *Const {
+	return &Const{object{pos: pos, name: name, typ: typ}, val}
+}
+
+func (obj *Const) Val() constant.Value { return obj.val }
+
+// Var is a variable, a parameter or a result. used is set once the value
+// of the variable is read.
+type Var struct {
+	object
+	used bool
+}
+
+func NewVar(pos diag.Position, name string, typ Type) *Var {
+	return &Var{object: object{pos: pos, name: name, typ: typ}}
+}
+
+// Func is a declared function, its type is a *Signature.
+type Func struct {
+	object
+}
+
+func NewFunc(pos diag.Position, name string, sig *Signature) *Func {
+	return &Func{object{pos: pos, name: name, typ: sig}}
+}
+
+// TypeName is the name of a type.
+type TypeName struct {
+	object
+}
+
+func NewTypeName(pos diag.Position, name string, typ Type) *TypeName {
+	return &TypeName{object{pos: pos, name: name, typ: typ}}
+}
+
+// Label is a statement label. Labels have their own scope per function.
+type Label struct {
+	object
+	used bool
+}
+
+func NewLabel(pos diag.Position, name string) *Label {
+	return &Label{object: object{pos: pos, name: name, typ: Typ[Invalid]}}
+}
+
+// Nil is the predeclared nil, the zero value of slices and maps.
+type Nil struct {
+	object
+}
+
+// builtinID identifies a builtin function.
+type builtinID int
+
+const (
+	_Print builtinID = iota
+	_Printf
+	_Len
+	_Cap
+	_Make
+	_Append
+	_Copy
+	_Delete
+)
+
+// Builtin is a predeclared function. It has no type, calls of builtins are
+// checked one by one.
+type Builtin struct {
+	object
+	id builtinID
+}
+
+func (b *Builtin) Type() Type { return Typ[Invalid] }
@@ -0,0 +1,100 @@
+package check
+
+import (
+	"fmt"
+	"go/constant"
+
+	"nano-go/ast"
+)
+
+type operandMode int
+
+const (
+	invalid   operandMode = iota // the operand has an error, it was reported
+	novalue                      // a call without results
+	builtin                      // a builtin function
+	typexpr                      // a type
+	constant_                    // a constant, val holds its value
+	value                        // a computed value
+	variable                     // an addressable variable
+	mapindex                     // a map index expression, it can be assigned to but not addressed
+)
+
+// operand is the result of checking an expression.
+type operand struct {
+	mode operandMode
+	expr ast.Expr
+	typ  Type
+	val  constant.Value
+	id   builtinID
+}
+
+// String describes the operand for diagnostics, e.g. "x (variable of type
+// int32)".
+func (x *operand) String() string {
+	expr := ExprString(x.expr)
+	if x.mode == value && x.typ == Typ[UntypedNil] {
+		return "nil"
+	}
+
+	switch x.mode {
+	case invalid:
+		return expr + " (invalid operand)"
+	case novalue:
+		return expr + " (no value)"
+	case builtin:
+		return expr + " (built-in)"
+	case typexpr:
+		return expr + " (type)"
+	case constant_:
+		// The value is only spelled out when it differs from the
+		// expression.
+		val := ""
+		if s := x.val.String(); s != expr {
+			val = " " + s
+		}
+		if isUntyped(x.typ) {
+			return fmt.Sprintf("%s (%s constant%s)", expr, x.typ, val)
+		}
+		return fmt.Sprintf("%s (constant%s of %s)", expr, val, typeDesc(x.typ))
+	}
+
+	what := "value"
+	switch x.mode {
+	case variable:
+		what = "variable"
+	case mapindex:
+		what = "map index expression"
+	}
+
+	if isUntyped(x.typ) {
+		return fmt.Sprintf("%s (%s %s)", expr, x.typ, what)
+	}
+	return fmt.Sprintf("%s (%s of %s)", expr, what, typeDesc(x.typ))
+}
+
+// typeDesc describes the type t of an operand. The structure of a declared
+// type is described too, e.g. "struct type T" or "int type T".
+func typeDesc(t Type) string {
+	n, ok := t.(*Named)
+	if !ok || n.Underlying() == Typ[Invalid] {
+		return "type " + t.String()
+	}
+
+	var what string
+	switch u := n.Underlying().(type) {
+	case *Basic:
+		what = u.name
+	case *Array:
+		what = "array"
+	case *Slice:
+		what = "slice"
+	case *Struct:
+		what = "struct"
+	case *Map:
+		what = "map"
+	case *Signature:
+		what = "func"
+	}
+	return what + " type " + t.String()
+}
@@ -0,0 +1,110 @@
+package check
+
+import (
+	"go/token"
+
+	"nano-go/ast"
+)
+
+// isTerminating reports whether s is a terminating statement as defined by
+// the Go spec. Functions with results must end in one. label is the label
+// of s, empty if it has none.
+func isTerminating(s ast.Stmt, label string) bool {
+	switch s := s.(type) {
+	case *ast.ReturnStmt:
+		return true
+
+	case *ast.BranchStmt:
+		return s.Tok == token.GOTO || s.Tok == token.FALLTHROUGH
+
+	case *ast.LabeledStmt:
+		return isTerminating(s.Stmt, s.Label.Name)
+
+	case *ast.BlockStmt:
+		return isTerminatingList(s.List, "")
+
+	case *ast.IfStmt:
+		return s.Else != nil && isTerminating(s.Body, "") && isTerminating(s.Else, "")
+
+	case *ast.SwitchStmt:
+		hasDefault := false
+		for _, clause := range s.Body.List {
+			clause := clause.(*ast.CaseClause)
+			if clause.List == nil {
+				hasDefault = true
+			}
+			if !isTerminatingList(clause.Body, "") || hasBreakList(clause.Body, label, true) {
+				return false
+			}
+		}
+		return hasDefault
+
+	case *ast.ForStmt:
+		return s.Cond == nil && !hasBreak(s.Body, label, true)
+	}
+
+	return false
+}
+
+// isTerminatingList reports whether the last non-empty statement of list is
+// terminating.
+func isTerminatingList(list []ast.Stmt, label string) bool {
+	for i := len(list) - 1; i >= 0; i-- {
+		if _, ok := list[i].(*ast.EmptyStmt); !ok {
+			return isTerminating(list[i], label)
+		}
+	}
+	return false
+}
+
+// hasBreak reports whether s contains a break statement referring to the
+// statement labeled label. implicit is set when an unlabeled break refers to
+// it as well.
+func hasBreak(s ast.Stmt, label string, implicit bool) bool {
+	switch s := s.(type) {
+	case *ast.LabeledStmt:
+		return hasBreak(s.Stmt, label, implicit)
+
+	case *ast.BranchStmt:
+		if s.Tok == token.BREAK {
+			if s.Label == nil {
+				return implicit
+			}
+			return s.Label.Name == label
+		}
+
+	case *ast.BlockStmt:
+		return hasBreakList(s.List, label, implicit)
+
+	case *ast.IfStmt:
+		return hasBreak(s.Body, label, implicit) || s.Else != nil && hasBreak(s.Else, label, implicit)
+
+	case *ast.SwitchStmt:
+		// Unlabeled breaks in a nested switch or loop refer to it.
+		if label == "" {
+			return false
+		}
+		for _, clause := range s.Body.List {
+			if hasBreakList(clause.(*ast.CaseClause).Body, label, false) {
+				return true
+			}
+		}
+
+	case *ast.ForStmt:
+		return label != "" && hasBreak(s.Body, label, false)
+
+	case *ast.RangeStmt:
+		return label != "" && hasBreak(s.Body, label, false)
+	}
+
+	return false
+}
+
+func hasBreakList(list []ast.Stmt, label string, implicit bool) bool {
+	for _, s := range list {
+		if hasBreak(s, label, implicit) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package check
+
+// Scope maps names to the objects declared in a block. Lookups that miss
+// continue in the parent scope.
+type Scope struct {
+	parent *Scope
+	elems  map[string]Object
+}
+
+func NewScope(parent *Scope) *Scope {
+	return &Scope{parent: parent}
+}
+
+func (s *Scope) Parent() *Scope { return s.parent }
+
+// Lookup returns the object declared in s with the given name, nil if
+// there is none. The parent scopes are not searched.
+func (s *Scope) Lookup(name string) Object {
+	return s.elems[name]
+}
+
+// LookupParent finds the object with the given name in s or the closest
+// enclosing scope that declares it.
+func (s *Scope) LookupParent(name string) Object {
+	for ; s != nil; s = s.parent {
+		if obj := s.Lookup(name); obj != nil {
+			return obj
+		}
+	}
+	return nil
+}
+
+// Insert declares obj in s. If s already declares an object with the same
+// name, that object is returned and s is left unchanged.
+func (s *Scope) Insert(obj Object) Object {
+	if alt := s.Lookup(obj.Name()); alt != nil {
+		return alt
+	}
+
+	if s.elems == nil {
+		s.elems = make(map[string]Object)
+	}
+	s.elems[obj.Name()] = obj
+	obj.setParent(s)
+	return nil
+}
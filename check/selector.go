@@ -0,0 +1,71 @@
+package check
+
+import (
+	"fmt"
+
+	"nano-go/ast"
+)
+
+// selector checks the selection x.f of a field of a struct. The field of a
+// variable is a variable too.
+func (c *Checker) selector(x *operand, e *ast.SelectorExpr) {
+	c.rawExpr(x, e.X)
+	switch x.mode {
+	case builtin:
+		c.errorf(e.Sel, "invalid use of %s in selector expression", x)
+		x.mode = invalid
+	case typexpr:
+	default:
+		c.singleValue(x)
+	}
+	if x.mode == invalid {
+		return
+	}
+
+	sel := e.Sel.Name
+	s, _ := x.typ.Underlying().(*Struct)
+	i := -1
+	if s != nil {
+		i = s.fieldIndex(sel, false)
+	}
+	if i < 0 {
+		c.errorf(e.Sel, "%s.%s undefined (%s)", ExprString(e.X), sel, lookupError(x.typ, s, sel, false))
+		x.mode = invalid
+		return
+	}
+
+	if x.mode == typexpr {
+		c.errorf(e.X, "operand for field selector %s must be value of type %s", sel, x.typ)
+		x.mode = invalid
+		return
+	}
+
+	field := s.fields[i]
+	c.info.Uses[e.Sel] = field
+	if x.mode != variable {
+		x.mode = value
+	}
+	x.typ = field.typ
+}
+
+// lookupError explains why the type typ with the underlying struct type s,
+// nil for other types, has no field sel. A field whose name only differs in
+// case is suggested.
+func lookupError(typ Type, s *Struct, sel string, structLit bool) string {
+	alt := ""
+	if s != nil {
+		if i := s.fieldIndex(sel, true); i >= 0 {
+			alt = s.fields[i].name
+		}
+	}
+
+	switch {
+	case structLit && alt != "":
+		return fmt.Sprintf("unknown field %s in struct literal of type %s, but does have %s", sel, typ, alt)
+	case structLit:
+		return fmt.Sprintf("unknown field %s in struct literal of type %s", sel, typ)
+	case alt != "":
+		return fmt.Sprintf("type %s has no field or method %s, but does have field %s", typ, sel, alt)
+	}
+	return fmt.Sprintf("type %s has no field or method %s", typ, sel)
+}
@@ -0,0 +1,552 @@
+package check
+
+import (
+	"go/constant"
+	"go/token"
+
+	"nano-go/ast"
+	"nano-go/diag"
+)
+
+// stmtContext describes where a statement appears.
+type stmtContext uint
+
+const (
+	breakOk         stmtContext = 1 << iota // break is permitted
+	continueOk                              // continue is permitted
+	fallthroughOk                           // fallthrough may end the statement list
+	finalSwitchCase                         // the statement list is the final case of a switch
+)
+
+func (c *Checker) stmtList(ctxt stmtContext, list []ast.Stmt) {
+	ok := ctxt&fallthroughOk != 0
+	inner := ctxt &^ fallthroughOk
+
+	// Only the last non-empty statement may fall through.
+	last := len(list) - 1
+	for last >= 0 {
+		if _, ok := list[last].(*ast.EmptyStmt); !ok {
+			break
+		}
+		last--
+	}
+
+	for i, s := range list {
+		ctxt := inner
+		if ok && i == last {
+			ctxt |= fallthroughOk
+		}
+		c.stmt(ctxt, s)
+	}
+}
+
+func (c *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
+	// The context only applies to the statement itself, not to the
+	// statements nested in it.
+	inner := ctxt &^ (fallthroughOk | finalSwitchCase)
+
+	switch s := s.(type) {
+	case *ast.BadStmt, *ast.EmptyStmt:
+
+	case *ast.ExprStmt:
+		c.exprStmt(s)
+
+	case *ast.IncDecStmt:
+		c.incDecStmt(s)
+
+	case *ast.AssignStmt:
+		switch s.Tok {
+		case token.DEFINE:
+			c.shortVarDecl(s)
+		case token.ASSIGN:
+			c.assignVars(s)
+		default:
+			c.assignOp(s)
+		}
+
+	case *ast.DeclStmt:
+		c.declStmt(s)
+
+	case *ast.ReturnStmt:
+		c.returnStmt(s)
+
+	case *ast.LabeledStmt:
+		c.stmt(ctxt, s.Stmt)
+
+	case *ast.BranchStmt:
+		c.branchStmt(ctxt, s)
+
+	case *ast.BlockStmt:
+		c.blockStmt(inner, s)
+
+	case *ast.IfStmt:
+		c.ifStmt(inner, s)
+
+	case *ast.SwitchStmt:
+		c.switchStmt(inner, s)
+
+	case *ast.ForStmt:
+		c.forStmt(inner, s)
+
+	case *ast.RangeStmt:
+		c.rangeStmt(inner, s)
+
+	default:
+		c.errorf(s, "%s is not implemented yet", stmtKind(s))
+	}
+}
+
+// stmtKind names the kind of an unsupported statement for diagnostics.
+func stmtKind(s ast.Stmt) string {
+	switch s := s.(type) {
+	case *ast.DeclStmt:
+		if d, ok := s.Decl.(*ast.GenDecl); ok {
+			return d.Tok.String() + " declaration"
+		}
+		return "declaration statement"
+	case *ast.SendStmt:
+		return "send statement"
+	case *ast.GoStmt:
+		return "go statement"
+	case *ast.DeferStmt:
+		return "defer statement"
+	}
+
+	return "statement"
+}
+
+func (c *Checker) declStmt(s *ast.DeclStmt) {
+	d, ok := s.Decl.(*ast.GenDecl)
+	if !ok {
+		c.errorf(s, "%s is not implemented yet", stmtKind(s))
+		return
+	}
+
+	switch d.Tok {
+	case token.CONST:
+		var last *ast.ValueSpec
+		for _, spec := range d.Specs {
+			s := spec.(*ast.ValueSpec)
+			last = inheritSpec(s, last)
+			c.arityMatch(s, last)
+
+			// The scope of the constants starts after the spec.
+			objs := make([]*Const, len(s.Names))
+			for i, name := range s.Names {
+				objs[i] = NewConst(name.Pos(), name.Name, nil, nil)
+				c.constDecl(objs[i], last.Type, valueAt(last, i), s.Iota)
+			}
+			for i, name := range s.Names {
+				c.declare(c.scope, name, objs[i])
+			}
+		}
+
+	case token.VAR:
+		for _, spec := range d.Specs {
+			s := spec.(*ast.ValueSpec)
+			c.varArity(s)
+
+			lhs := make([]*Var, len(s.Names))
+			for i, name := range s.Names {
+				lhs[i] = NewVar(name.Pos(), name.Name, nil)
+			}
+
+			if len(s.Values) == 1 && len(s.Names) > 1 {
+				c.varDecl(lhs, s.Type, s.Values[0], lhs[0])
+			} else {
+				for i, v := range lhs {
+					c.varDecl([]*Var{v}, s.Type, valueAt(s, i), v)
+				}
+			}
+
+			// The scope of the variables starts after the spec.
+			for i, name := range s.Names {
+				c.declare(c.scope, name, lhs[i])
+			}
+		}
+
+	case token.TYPE:
+		for _, spec := range d.Specs {
+			s := spec.(*ast.TypeSpec)
+			obj := NewTypeName(s.Name.Pos(), s.Name.Name, nil)
+
+			// The scope of the type starts at its name, it may refer
+			// to itself like a package-level one.
+			c.declare(c.scope, s.Name, obj)
+			c.resolving[obj] = true
+			c.typeDecl(obj, s)
+			delete(c.resolving, obj)
+		}
+
+	default:
+		c.errorf(s, "%s is not implemented yet", stmtKind(s))
+	}
+}
+
+func (c *Checker) exprStmt(s *ast.ExprStmt) {
+	var x operand
+	c.rawExpr(&x, s.X)
+
+	var msg string
+	switch x.mode {
+	case invalid, novalue:
+		return
+	case builtin:
+		msg = "must be called"
+	case typexpr:
+		msg = "is not an expression"
+	default:
+		// Calls are statements, except the ones of the builtins computing
+		// a value like len or append.
+		if call, ok := unparen(s.X).(*ast.CallExpr); ok {
+			id, _ := unparen(call.Fun).(*ast.Ident)
+			if b, isBuiltin := c.info.Uses[id].(*Builtin); !isBuiltin || b.id == _Copy {
+				return
+			}
+		}
+		msg = "is not used"
+	}
+
+	c.errorf(s, "%s %s", &x, msg)
+}
+
+func (c *Checker) incDecStmt(s *ast.IncDecStmt) {
+	var x operand
+	c.expr(&x, s.X)
+	if x.mode == invalid {
+		return
+	}
+
+	if !isNumeric(x.typ) {
+		c.errorf(s.X, "invalid operation: %s%s (non-numeric type %s)", ExprString(s.X), s.Tok, x.typ)
+		return
+	}
+
+	if x.mode != variable && x.mode != mapindex {
+		c.errorf(s.X, "cannot assign to %s (neither addressable nor a map index expression)", &x)
+	}
+}
+
+func (c *Checker) returnStmt(s *ast.ReturnStmt) {
+	results := c.sig.results
+
+	if len(s.Results) == 0 {
+		if results.Len() > 0 && results.At(0).name == "" {
+			c.errorf(s, "not enough return values\n\thave ()\n\twant %s", results)
+			return
+		}
+
+		// A bare return returns the named results, they must not be
+		// shadowed.
+		for i := 0; i < results.Len(); i++ {
+			res := results.At(i)
+			if res.name == "_" {
+				continue
+			}
+			if alt := c.scope.LookupParent(res.name); alt != Object(res) {
+				c.errorf(s, "result parameter %s not in scope at return", res.name)
+				c.diags.Notef(alt.Pos(), "inner declaration of %s", res.name)
+			}
+		}
+		return
+	}
+
+	ops := c.exprList(s.Results)
+	for _, x := range ops {
+		if x.mode == invalid {
+			return
+		}
+	}
+
+	if len(ops) != results.Len() {
+		qualifier := "not enough"
+		if len(ops) > results.Len() {
+			qualifier = "too many"
+		}
+		c.errorf(s.Results[0], "%s return values\n\thave %s\n\twant %s",
+			qualifier, operandTypes(ops), results.signatureString())
+		return
+	}
+
+	for i, x := range ops {
+		c.assignment(x, results.At(i).typ, "return statement")
+	}
+}
+
+// branchStmt checks a branch statement without a label, the labels are
+// checked by labels.
+func (c *Checker) branchStmt(ctxt stmtContext, s *ast.BranchStmt) {
+	switch s.Tok {
+	case token.BREAK:
+		if s.Label == nil && ctxt&breakOk == 0 {
+			c.errorf(s, "break is not in a loop, switch, or select")
+		}
+	case token.CONTINUE:
+		if s.Label == nil && ctxt&continueOk == 0 {
+			c.errorf(s, "continue is not in a loop")
+		}
+	case token.FALLTHROUGH:
+		if ctxt&fallthroughOk == 0 {
+			if ctxt&finalSwitchCase != 0 {
+				c.errorf(s, "cannot fallthrough final case in switch")
+			} else {
+				c.errorf(s, "fallthrough statement out of place")
+			}
+		}
+	}
+}
+
+func (c *Checker) blockStmt(ctxt stmtContext, s *ast.BlockStmt) {
+	c.openScope()
+	defer c.closeScope()
+
+	c.stmtList(ctxt, s.List)
+}
+
+// condition checks the condition of an if or a for statement.
+func (c *Checker) condition(e ast.Expr, what string) {
+	var x operand
+	c.expr(&x, e)
+	if x.mode == invalid {
+		return
+	}
+
+	if !isBoolean(x.typ) {
+		c.errorf(e, "non-boolean condition in %s statement", what)
+		return
+	}
+
+	if isUntyped(x.typ) {
+		c.convertUntyped(&x, Default(x.typ))
+	}
+}
+
+func (c *Checker) ifStmt(ctxt stmtContext, s *ast.IfStmt) {
+	c.openScope()
+	defer c.closeScope()
+
+	if s.Init != nil {
+		c.stmt(ctxt, s.Init)
+	}
+	c.condition(s.Cond, "if")
+	c.blockStmt(ctxt, s.Body)
+
+	if s.Else != nil {
+		c.stmt(ctxt, s.Else)
+	}
+}
+
+func (c *Checker) switchStmt(ctxt stmtContext, s *ast.SwitchStmt) {
+	c.openScope()
+	defer c.closeScope()
+
+	if s.Init != nil {
+		c.stmt(ctxt, s.Init)
+	}
+
+	var x operand
+	if s.Tag != nil {
+		c.expr(&x, s.Tag)
+		c.assignment(&x, nil, "switch expression")
+		if x.mode != invalid && !comparable(x.typ) && !hasNil(x.typ) {
+			c.errorf(x.expr, "cannot switch on %s (%s is not comparable)", &x, x.typ)
+			x.mode = invalid
+		}
+	} else {
+		// A missing tag is the constant true.
+		x.mode = constant_
+		x.typ = Typ[Bool]
+		x.val = constant.MakeBool(true)
+		x.expr = &ast.Ident{NamePos: s.Body.Lbrace, Name: "true"}
+	}
+
+	c.multipleDefaults(s.Body.List)
+
+	seen := make(map[string][]caseValue)
+	for i, clause := range s.Body.List {
+		clause := clause.(*ast.CaseClause)
+		c.caseValues(&x, clause.List, seen)
+
+		inner := ctxt | breakOk
+		if i+1 < len(s.Body.List) {
+			inner |= fallthroughOk
+		} else {
+			inner |= finalSwitchCase
+		}
+
+		c.openScope()
+		c.stmtList(inner, clause.Body)
+		c.closeScope()
+	}
+}
+
+func (c *Checker) multipleDefaults(list []ast.Stmt) {
+	var first *ast.CaseClause
+	for _, clause := range list {
+		clause := clause.(*ast.CaseClause)
+		if clause.List != nil {
+			continue
+		}
+		if first != nil {
+			c.diags.Errorf(clause.Case, "multiple defaults in switch")
+			c.diags.Notef(first.Case, "previous default")
+			continue
+		}
+		first = clause
+	}
+}
+
+// caseValue is a constant case value that was already seen in a switch.
+type caseValue struct {
+	pos diag.Position
+	typ Type
+}
+
+// caseValues checks that the case values can be compared with the switch
+// tag x. Duplicate constants are recorded in seen, keyed by their value.
+func (c *Checker) caseValues(x *operand, values []ast.Expr, seen map[string][]caseValue) {
+	for _, e := range values {
+		var v operand
+		c.expr(&v, e)
+		if x.mode == invalid || v.mode == invalid {
+			continue
+		}
+
+		// The case value is compared against the tag so that errors are
+		// reported at the case.
+		cmp := &ast.BinaryExpr{X: e, OpPos: e.Pos(), Op: token.EQL, Y: x.expr}
+		res, y := v, *x
+		c.matchTypes(&res, &y)
+		if res.mode == invalid || y.mode == invalid {
+			continue
+		}
+		if !Identical(res.typ, y.typ) {
+			c.errorf(e, "invalid case %s in switch on %s (mismatched types %s and %s)",
+				ExprString(e), ExprString(x.expr), res.typ, y.typ)
+			continue
+		}
+		v = res
+		c.comparison(&res, &y, cmp, true)
+		if res.mode == invalid || v.mode != constant_ {
+			continue
+		}
+
+		key := v.val.ExactString()
+		for _, prev := range seen[key] {
+			if Identical(v.typ, prev.typ) {
+				c.errorf(e, "duplicate case %s in expression switch", ExprString(e))
+				c.diags.Notef(prev.pos, "previous case")
+			}
+		}
+		seen[key] = append(seen[key], caseValue{e.Pos(), v.typ})
+	}
+}
+
+func (c *Checker) forStmt(ctxt stmtContext, s *ast.ForStmt) {
+	c.openScope()
+	defer c.closeScope()
+
+	if s.Init != nil {
+		c.stmt(ctxt, s.Init)
+	}
+	if s.Cond != nil {
+		c.condition(s.Cond, "for")
+	}
+	if s.Post != nil {
+		if assign, ok := s.Post.(*ast.AssignStmt); ok && assign.Tok == token.DEFINE {
+			c.errorf(s.Post, "cannot declare in post statement of for loop")
+		} else {
+			c.stmt(ctxt, s.Post)
+		}
+	}
+
+	c.blockStmt(ctxt|breakOk|continueOk, s.Body)
+}
+
+func (c *Checker) rangeStmt(ctxt stmtContext, s *ast.RangeStmt) {
+	var x operand
+	c.expr(&x, s.X)
+
+	// The types of the iteration values, valT is nil when there is only
+	// one.
+	var keyT, valT Type
+	if x.mode != invalid {
+		var ok bool
+		keyT, valT, ok = rangeKeyVal(Default(x.typ))
+		switch {
+		case !ok:
+			c.errorf(x.expr, "cannot range over %s", &x)
+			x.mode = invalid
+		case s.Value != nil && valT == nil:
+			c.errorf(s.Value, "range over %s permits only one iteration variable", &x)
+			x.mode = invalid
+		case isUntyped(x.typ):
+			c.assignment(&x, nil, "range clause")
+		}
+	}
+	if x.mode == invalid {
+		keyT, valT = Typ[Invalid], Typ[Invalid]
+	}
+
+	c.openScope()
+	defer c.closeScope()
+
+	lhs := [2]ast.Expr{s.Key, s.Value}
+	rhs := [2]Type{keyT, valT}
+
+	switch s.Tok {
+	case token.DEFINE:
+		// The scope of the iteration variables starts at the body.
+		var vars []*Var
+		var ids []*ast.Ident
+		for i, e := range lhs {
+			if e == nil {
+				continue
+			}
+			id := e.(*ast.Ident)
+			v := NewVar(id.Pos(), id.Name, rhs[i])
+			c.info.Defs[id] = v
+			vars = append(vars, v)
+			ids = append(ids, id)
+		}
+		for i, v := range vars {
+			c.declare(c.scope, ids[i], v)
+		}
+
+	case token.ASSIGN:
+		for i, e := range lhs {
+			if e == nil {
+				continue
+			}
+			T := c.lhsVar(e)
+			if T == nil || T == Typ[Invalid] || rhs[i] == Typ[Invalid] {
+				continue
+			}
+			y := operand{mode: value, expr: e, typ: rhs[i]}
+			c.assignment(&y, T, "range clause")
+		}
+	}
+
+	c.blockStmt(ctxt|breakOk|continueOk, s.Body)
+}
+
+// rangeKeyVal returns the types of the iteration values of a range loop over
+// a value of type typ, val is nil when there is only one.
+func rangeKeyVal(typ Type) (key, val Type, ok bool) {
+	switch typ := typ.Underlying().(type) {
+	case *Basic:
+		switch {
+		case typ.is(IsString):
+			return Typ[Int], Typ[Rune], true
+		case typ.is(IsInteger):
+			return typ, nil, true
+		}
+	case *Array:
+		return Typ[Int], typ.elem, true
+	case *Slice:
+		return Typ[Int], typ.elem, true
+	case *Map:
+		return typ.key, typ.elem, true
+	}
+	return nil, nil, false
+}
@@ -0,0 +1,505 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Type is a Go type. Types are compared with Identical, basic types may also
+// be compared by pointer.
+type Type interface {
+	// Underlying returns the underlying type of the type.
+	Underlying() Type
+	String() string
+}
+
+type BasicKind int
+
+const (
+	Invalid BasicKind = iota
+
+	// Predeclared types
+	Bool
+	Int
+	Int8
+	Int16
+	Int32
+	Int64
+	Uint
+	Uint8
+	Uint16
+	Uint32
+	Uint64
+	Uintptr
+	Float32
+	Float64
+	String
+
+	// Types of untyped values
+	UntypedBool
+	UntypedInt
+	UntypedRune
+	UntypedFloat
+	UntypedString
+	UntypedNil
+
+	// Aliases
+	Byte = Uint8
+	Rune = Int32
+)
+
+// BasicInfo is a set of properties of a basic type.
+type BasicInfo int
+
+const (
+	IsBoolean BasicInfo = 1 << iota
+	IsInteger
+	IsUnsigned
+	IsFloat
+	IsString
+	IsUntyped
+
+	IsOrdered   = IsInteger | IsFloat | IsString
+	IsNumeric   = IsInteger | IsFloat
+	IsConstType = IsBoolean | IsNumeric | IsString
+)
+
+// Basic is a predeclared type or the type of an untyped value.
+type Basic struct {
+	kind BasicKind
+	info BasicInfo
+	name string
+}
+
+func (b *Basic) Kind() BasicKind     { return b.kind }
+func (b *Basic) Info() BasicInfo     { return b.info }
+func (b *Basic) Name() string        { return b.name }
+func (b *Basic) Underlying() Type    { return b }
+func (b *Basic) String() string      { return b.name }
+func (b *Basic) is(i BasicInfo) bool { return b.info&i != 0 }
+
+// Typ holds the basic types indexed by their kind.
+var Typ = [...]*Basic{
+	Invalid: {Invalid, 0, "invalid type"},
+
+	Bool:    {Bool, IsBoolean, "bool"},
+	Int:     {Int, IsInteger, "int"},
+	Int8:    {Int8, IsInteger, "int8"},
+	Int16:   {Int16, IsInteger, "int16"},
+	Int32:   {Int32, IsInteger, "int32"},
+	Int64:   {Int64, IsInteger, "int64"},
+	Uint:    {Uint, IsInteger | IsUnsigned, "uint"},
+	Uint8:   {Uint8, IsInteger | IsUnsigned, "uint8"},
+	Uint16:  {Uint16, IsInteger | IsUnsigned, "uint16"},
+	Uint32:  {Uint32, IsInteger | IsUnsigned, "uint32"},
+	Uint64:  {Uint64, IsInteger | IsUnsigned, "uint64"},
+	Uintptr: {Uintptr, IsInteger | IsUnsigned, "uintptr"},
+	Float32: {Float32, IsFloat, "float32"},
+	Float64: {Float64, IsFloat, "float64"},
+	String:  {String, IsString, "string"},
+
+	UntypedBool:   {UntypedBool, IsBoolean | IsUntyped, "untyped bool"},
+	UntypedInt:    {UntypedInt, IsInteger | IsUntyped, "untyped int"},
+	UntypedRune:   {UntypedRune, IsInteger | IsUntyped, "untyped rune"},
+	UntypedFloat:  {UntypedFloat, IsFloat | IsUntyped, "untyped float"},
+	UntypedString: {UntypedString, IsString | IsUntyped, "untyped string"},
+	UntypedNil:    {UntypedNil, IsUntyped, "untyped nil"},
+}
+
+// Tuple is the ordered list of parameters or results of a signature, or
+// the types of a multi-valued expression.
+type Tuple struct {
+	vars []*Var
+}
+
+func NewTuple(vars ...*Var) *Tuple {
+	if len(vars) == 0 {
+		return nil
+	}
+	return &Tuple{vars}
+}
+
+// Len returns the number of variables of the tuple, a nil tuple is empty.
+func (t *Tuple) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.vars)
+}
+
+func (t *Tuple) At(i int) *Var { return t.vars[i] }
+
+func (t *Tuple) Underlying() Type { return t }
+
+func (t *Tuple) String() string {
+	var buf bytes.Buffer
+	buf.WriteByte('(')
+	for i, v := range t.vars {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(v.typ.String())
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+// Array is an array type.
+type Array struct {
+	len  int64
+	elem Type
+}
+
+func NewArray(elem Type, len int64) *Array { return &Array{len: len, elem: elem} }
+
+func (a *Array) Len() int64       { return a.len }
+func (a *Array) Elem() Type       { return a.elem }
+func (a *Array) Underlying() Type { return a }
+func (a *Array) String() string   { return fmt.Sprintf("[%d]%s", a.len, a.elem) }
+
+// Slice is a slice type.
+type Slice struct {
+	elem Type
+}
+
+func NewSlice(elem Type) *Slice { return &Slice{elem} }
+
+func (s *Slice) Elem() Type       { return s.elem }
+func (s *Slice) Underlying() Type { return s }
+func (s *Slice) String() string   { return "[]" + s.elem.String() }
+
+// Map is a map type.
+type Map struct {
+	key, elem Type
+}
+
+func NewMap(key, elem Type) *Map { return &Map{key: key, elem: elem} }
+
+func (m *Map) Key() Type        { return m.key }
+func (m *Map) Elem() Type       { return m.elem }
+func (m *Map) Underlying() Type { return m }
+func (m *Map) String() string   { return fmt.Sprintf("map[%s]%s", m.key, m.elem) }
+
+// Struct is a struct type. The tags of the fields are part of the type.
+type Struct struct {
+	fields []*Var
+	tags   []string
+}
+
+func NewStruct(fields []*Var, tags []string) *Struct { return &Struct{fields: fields, tags: tags} }
+
+func (s *Struct) NumFields() int   { return len(s.fields) }
+func (s *Struct) Field(i int) *Var { return s.fields[i] }
+func (s *Struct) Tag(i int) string { return s.tags[i] }
+func (s *Struct) Underlying() Type { return s }
+
+func (s *Struct) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("struct{")
+	for i, f := range s.fields {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(f.name + " " + f.typ.String())
+		if s.tags[i] != "" {
+			buf.WriteString(" " + strconv.Quote(s.tags[i]))
+		}
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// fieldIndex returns the index of the field name, -1 if there is none. The
+// case of the names is ignored with foldCase.
+func (s *Struct) fieldIndex(name string, foldCase bool) int {
+	if name == "_" {
+		return -1
+	}
+	for i, f := range s.fields {
+		if f.name == name || foldCase && strings.EqualFold(f.name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Named is a type declared with a name. Its underlying type is invalid
+// until the declaration is checked. A type declared as another named type
+// refers to it, so that it may be declared later.
+type Named struct {
+	obj        *TypeName
+	underlying Type
+}
+
+// NewNamed makes the type of the type name obj.
+func NewNamed(obj *TypeName, underlying Type) *Named {
+	t := &Named{obj: obj, underlying: underlying}
+	obj.typ = t
+	return t
+}
+
+func (n *Named) Obj() *TypeName { return n.obj }
+
+func (n *Named) Underlying() Type {
+	t := n.underlying
+	for {
+		switch u := t.(type) {
+		case nil:
+			return Typ[Invalid]
+		case *Named:
+			t = u.underlying
+		default:
+			return t
+		}
+	}
+}
+
+func (n *Named) String() string { return n.obj.name }
+
+// Signature is the type of a function. The final parameter of a variadic
+// function is a slice.
+type Signature struct {
+	params   *Tuple
+	results  *Tuple
+	variadic bool
+}
+
+func NewSignature(params, results *Tuple, variadic bool) *Signature {
+	return &Signature{params: params, results: results, variadic: variadic}
+}
+
+func (s *Signature) Params() *Tuple   { return s.params }
+func (s *Signature) Results() *Tuple  { return s.results }
+func (s *Signature) Variadic() bool   { return s.variadic }
+func (s *Signature) Underlying() Type { return s }
+
+func (s *Signature) String() string {
+	str := "func" + s.params.paramsString(s.variadic)
+	switch s.results.Len() {
+	case 0:
+	case 1:
+		str += " " + s.results.At(0).typ.String()
+	default:
+		str += " " + s.results.String()
+	}
+	return str
+}
+
+func (t *Tuple) signatureString() string {
+	if t == nil {
+		return "()"
+	}
+	return t.String()
+}
+
+// paramsString describes the parameters of a signature, the final one is
+// written ...T when it is variadic.
+func (t *Tuple) paramsString(variadic bool) string {
+	var buf bytes.Buffer
+	buf.WriteByte('(')
+	for i := 0; i < t.Len(); i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		typ := t.vars[i].typ
+		if variadic && i == t.Len()-1 {
+			buf.WriteString("...")
+			typ = typ.(*Slice).elem
+		}
+		buf.WriteString(typ.String())
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+// Identical reports whether x and y are the same type.
+func Identical(x, y Type) bool {
+	if x == y {
+		return true
+	}
+
+	switch x := x.(type) {
+	case *Tuple:
+		y, ok := y.(*Tuple)
+		if !ok || x.Len() != y.Len() {
+			return false
+		}
+		for i := 0; i < x.Len(); i++ {
+			if !Identical(x.At(i).typ, y.At(i).typ) {
+				return false
+			}
+		}
+		return true
+
+	case *Array:
+		y, ok := y.(*Array)
+		return ok && x.len == y.len && Identical(x.elem, y.elem)
+
+	case *Slice:
+		y, ok := y.(*Slice)
+		return ok && Identical(x.elem, y.elem)
+
+	case *Struct:
+		y, ok := y.(*Struct)
+		if !ok || len(x.fields) != len(y.fields) {
+			return false
+		}
+		for i, f := range x.fields {
+			g := y.fields[i]
+			if f.name != g.name || x.tags[i] != y.tags[i] || !Identical(f.typ, g.typ) {
+				return false
+			}
+		}
+		return true
+
+	case *Map:
+		y, ok := y.(*Map)
+		return ok && Identical(x.key, y.key) && Identical(x.elem, y.elem)
+
+	case *Signature:
+		y, ok := y.(*Signature)
+		return ok && x.variadic == y.variadic &&
+			Identical(tupleType(x.params), tupleType(y.params)) &&
+			Identical(tupleType(x.results), tupleType(y.results))
+	}
+
+	return false
+}
+
+// tupleType makes a nil tuple comparable by Identical.
+func tupleType(t *Tuple) Type {
+	if t == nil {
+		return &Tuple{}
+	}
+	return t
+}
+
+func isBasic(t Type, info BasicInfo) bool {
+	b, ok := t.Underlying().(*Basic)
+	return ok && b.is(info)
+}
+
+func isBoolean(t Type) bool  { return isBasic(t, IsBoolean) }
+func isInteger(t Type) bool  { return isBasic(t, IsInteger) }
+func isUnsigned(t Type) bool { return isBasic(t, IsUnsigned) }
+func isString(t Type) bool   { return isBasic(t, IsString) }
+func isNumeric(t Type) bool  { return isBasic(t, IsNumeric) }
+func isOrdered(t Type) bool  { return isBasic(t, IsOrdered) }
+func isUntyped(t Type) bool  { return isBasic(t, IsUntyped) }
+
+// isNamed reports whether t is a predeclared or a declared type.
+func isNamed(t Type) bool {
+	switch t.(type) {
+	case *Basic, *Named:
+		return true
+	}
+	return false
+}
+
+// isConstType reports whether t can be the type of a constant.
+func isConstType(t Type) bool { return isBasic(t, IsConstType) }
+
+// sizeof returns the size in bytes of the values of the basic type t on the
+// 64-bit targets of the compiler.
+func sizeof(t Type) int64 {
+	switch t.Underlying().(*Basic).kind {
+	case Bool, Int8, Uint8:
+		return 1
+	case Int16, Uint16:
+		return 2
+	case Int32, Uint32, Float32:
+		return 4
+	case String:
+		return 16
+	}
+	return 8
+}
+
+// comparable reports whether values of type t can be compared with ==.
+func comparable(t Type) bool {
+	switch t := t.Underlying().(type) {
+	case *Basic:
+		return true
+	case *Array:
+		return comparable(t.elem)
+	case *Struct:
+		for _, f := range t.fields {
+			if !comparable(f.typ) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// hasNil reports whether nil is a value of type t.
+func hasNil(t Type) bool {
+	switch t.Underlying().(type) {
+	case *Slice, *Map:
+		return true
+	}
+	return false
+}
+
+// kindString describes the kind of the type t in errors, composite types
+// are only named by their kind.
+func kindString(t Type) string {
+	switch t.Underlying().(type) {
+	case *Array:
+		return "array"
+	case *Slice:
+		return "slice"
+	case *Struct:
+		return "struct"
+	case *Map:
+		return "map"
+	case *Signature:
+		return "func"
+	}
+	return t.String()
+}
+
+// incomparableCause explains why the values of type t cannot be compared,
+// it is empty when the comparison operators are not defined on t.
+func incomparableCause(t Type) string {
+	switch u := t.Underlying().(type) {
+	case *Slice:
+		return "slice can only be compared to nil"
+	case *Map:
+		return "map can only be compared to nil"
+	case *Array:
+		if !comparable(u.elem) {
+			return fmt.Sprintf("%s cannot be compared", t)
+		}
+	case *Struct:
+		for _, f := range u.fields {
+			if !comparable(f.typ) {
+				return fmt.Sprintf("struct containing %s cannot be compared", f.typ)
+			}
+		}
+	}
+	return ""
+}
+
+// Default returns the type an untyped value of type t gets when the context
+// does not give it one, t itself for typed values.
+func Default(t Type) Type {
+	if b, ok := t.(*Basic); ok {
+		switch b.kind {
+		case UntypedBool:
+			return Typ[Bool]
+		case UntypedInt:
+			return Typ[Int]
+		case UntypedRune:
+			return Typ[Rune]
+		case UntypedFloat:
+			return Typ[Float64]
+		case UntypedString:
+			return Typ[String]
+		}
+	}
+	return t
+}
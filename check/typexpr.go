@@ -0,0 +1,224 @@
+package check
+
+import (
+	"go/constant"
+	"strconv"
+
+	"nano-go/ast"
+)
+
+// typExpr checks that e denotes a type and returns it, Typ[Invalid] after
+// an error.
+func (c *Checker) typExpr(e ast.Expr) Type {
+	switch e := e.(type) {
+	case *ast.Ident:
+		var x operand
+		c.ident(&x, e)
+		switch x.mode {
+		case invalid:
+		case typexpr:
+			c.record(&x)
+			return x.typ
+		default:
+			c.errorf(e, "%s is not a type", x.String())
+		}
+
+	case *ast.ParenExpr:
+		return c.typExpr(e.X)
+
+	case *ast.ArrayType:
+		if e.Len == nil {
+			elem := c.typExpr(e.Elt)
+			if elem == Typ[Invalid] {
+				break
+			}
+			return NewSlice(elem)
+		}
+		if _, ok := e.Len.(*ast.Ellipsis); ok {
+			c.errorf(e.Len, "invalid use of [...] array (outside a composite literal)")
+			c.typExpr(e.Elt)
+			break
+		}
+
+		typ := NewArray(c.typExpr(e.Elt), c.arrayLength(e.Len))
+		if typ.len < 0 || typ.elem == Typ[Invalid] {
+			break
+		}
+		return typ
+
+	case *ast.MapType:
+		key := c.typExpr(e.Key)
+		elem := c.typExpr(e.Value)
+		if key == Typ[Invalid] || elem == Typ[Invalid] {
+			break
+		}
+		if !comparable(key) {
+			c.errorf(e.Key, "invalid map key type %s", key)
+			break
+		}
+		return NewMap(key, elem)
+
+	case *ast.StructType:
+		return c.structType(e)
+
+	case *ast.BadExpr:
+
+	default:
+		c.errorf(e, "%s is not implemented yet", typeKind(e))
+	}
+
+	return Typ[Invalid]
+}
+
+// arrayLength checks the length of an array type and returns it, -1 after
+// an error.
+func (c *Checker) arrayLength(e ast.Expr) int64 {
+	// A name that does not denote a constant is not described.
+	if name, ok := e.(*ast.Ident); ok {
+		if obj := c.scope.LookupParent(name.Name); obj != nil {
+			if _, ok := obj.(*Const); !ok {
+				c.errorf(name, "invalid array length %s", name.Name)
+				return -1
+			}
+		}
+	}
+
+	var x operand
+	c.expr(&x, e)
+	if x.mode == invalid {
+		return -1
+	}
+
+	if x.mode != constant_ {
+		c.errorf(e, "array length %s must be constant", &x)
+		return -1
+	}
+
+	if isUntyped(x.typ) || isInteger(x.typ) {
+		if val := constant.ToInt(x.val); val.Kind() == constant.Int {
+			if n, ok := constant.Int64Val(val); ok && n >= 0 {
+				return n
+			}
+		}
+	}
+
+	if isInteger(x.typ) || isUntyped(x.typ) && x.val.Kind() == constant.Int {
+		c.errorf(e, "invalid array length %s", &x)
+	} else {
+		c.errorf(e, "array length %s must be integer", &x)
+	}
+	return -1
+}
+
+// structType builds the struct type of e, the names of its fields must be
+// unique. Blank fields may repeat.
+func (c *Checker) structType(e *ast.StructType) Type {
+	var fields []*Var
+	var tags []string
+	seen := make(map[string]*Var)
+	invalid := false
+
+	for _, field := range e.Fields.List {
+		typ := c.typExpr(field.Type)
+		if len(field.Names) == 0 {
+			c.errorf(field.Type, "embedded fields are not implemented yet")
+			invalid = true
+			continue
+		}
+		invalid = invalid || typ == Typ[Invalid]
+
+		var tag string
+		if field.Tag != nil {
+			tag, _ = strconv.Unquote(field.Tag.Value)
+		}
+
+		for _, name := range field.Names {
+			f := NewVar(name.Pos(), name.Name, typ)
+			c.info.Defs[name] = f
+			if alt := seen[name.Name]; alt != nil {
+				c.errorf(name, "%s redeclared", name.Name)
+				c.diags.Notef(alt.pos, "other declaration of %s", name.Name)
+				invalid = true
+				continue
+			}
+			if name.Name != "_" {
+				seen[name.Name] = f
+			}
+			fields = append(fields, f)
+			tags = append(tags, tag)
+		}
+	}
+
+	if invalid {
+		return Typ[Invalid]
+	}
+	return NewStruct(fields, tags)
+}
+
+// typeKind names the kind of a type expression for diagnostics.
+func typeKind(e ast.Expr) string {
+	switch e.(type) {
+	case *ast.StarExpr:
+		return "pointer types"
+	case *ast.FuncType:
+		return "function types"
+	case *ast.InterfaceType:
+		return "interface types"
+	case *ast.ChanType:
+		return "channel types"
+	case *ast.SelectorExpr:
+		return "qualified identifiers"
+	}
+
+	return "type " + ExprString(e)
+}
+
+// funcType builds the signature of a function declaration.
+func (c *Checker) funcType(ftype *ast.FuncType) *Signature {
+	params, variadic := c.collectParams(ftype.Params, true)
+	results, _ := c.collectParams(ftype.Results, false)
+	return NewSignature(params, results, variadic)
+}
+
+// collectParams builds the tuple of the parameters or results of a
+// function. The final parameter may be variadic, it is a slice of the type
+// after the ... then.
+func (c *Checker) collectParams(list *ast.FieldList, variadicOk bool) (*Tuple, bool) {
+	if list == nil {
+		return nil, false
+	}
+
+	var vars []*Var
+	var variadic bool
+	for i, field := range list.List {
+		var typ Type
+		if t, ok := field.Type.(*ast.Ellipsis); ok {
+			typ = c.typExpr(t.Elt)
+			if typ != Typ[Invalid] {
+				typ = NewSlice(typ)
+			}
+
+			switch {
+			case !variadicOk:
+				c.errorf(t, "invalid use of ...")
+			case i < len(list.List)-1 || len(field.Names) > 1:
+				c.errorf(t, "can only use ... with final parameter in list")
+			default:
+				variadic = typ != Typ[Invalid]
+			}
+		} else {
+			typ = c.typExpr(field.Type)
+		}
+
+		if len(field.Names) == 0 {
+			vars = append(vars, NewVar(field.Type.Pos(), "", typ))
+			continue
+		}
+
+		for _, name := range field.Names {
+			vars = append(vars, NewVar(name.Pos(), name.Name, typ))
+		}
+	}
+
+	return NewTuple(vars...), variadic
+}
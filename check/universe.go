@@ -0,0 +1,63 @@
+package check
+
+import "go/constant"
+
+// Universe is the outermost scope, it holds the predeclared objects.
+var Universe *Scope
+
+// universeTypes are the predeclared types the compiler can lower so far.
+var universeTypes = []BasicKind{
+	Bool,
+	Int, Int8, Int16, Int32, Int64,
+	Uint, Uint8, Uint16, Uint32, Uint64, Uintptr,
+	Float32, Float64,
+	String,
+}
+
+// universeAliases are the predeclared names of aliases of basic types.
+var universeAliases = map[string]BasicKind{
+	"byte": Byte,
+	"rune": Rune,
+}
+
+// universeIota is the predeclared iota, its value is given by the constant
+// declaration it is used in.
+var universeIota = NewConst(noPos, "iota", Typ[UntypedInt], constant.MakeInt64(0))
+
+var universeConsts = []*Const{
+	NewConst(noPos, "true", Typ[UntypedBool], constant.MakeBool(true)),
+	NewConst(noPos, "false", Typ[UntypedBool], constant.MakeBool(false)),
+}
+
+var universeBuiltins = map[string]builtinID{
+	"Print":  _Print,
+	"Printf": _Printf,
+	"len":    _Len,
+	"cap":    _Cap,
+	"make":   _Make,
+	"append": _Append,
+	"copy":   _Copy,
+	"delete": _Delete,
+}
+
+func init() {
+	Universe = NewScope(nil)
+
+	for _, kind := range universeTypes {
+		Universe.Insert(NewTypeName(noPos, Typ[kind].name, Typ[kind]))
+	}
+
+	for name, kind := range universeAliases {
+		Universe.Insert(NewTypeName(noPos, name, Typ[kind]))
+	}
+
+	for _, obj := range universeConsts {
+		Universe.Insert(obj)
+	}
+	Universe.Insert(universeIota)
+	Universe.Insert(&Nil{object{name: "nil", typ: Typ[UntypedNil]}})
+
+	for name, id := range universeBuiltins {
+		Universe.Insert(&Builtin{object{name: name, typ: Typ[Invalid]}, id})
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+
+	"nano-go/check"
+	"nano-go/diag"
+	"nano-go/parser"
+	"nano-go/visitor"
+)
+
+const defaultGOOS = "linux"
+
+// Target triples of the supported operating systems.
+var targetTriples = map[string]string{
+	"linux":  "x86_64-unknown-linux-gnu",
+	"darwin": "x86_64-apple-macosx10.14.0",
+}
+
+// Artifact kinds the build command can emit.
+const (
+	kindLL  = "ll"
+	kindAsm = "asm"
+	kindObj = "obj"
+	kindExe = "exe"
+)
+
+var kindExtensions = map[string]string{
+	kindLL:  ".ll",
+	kindAsm: ".s",
+	kindObj: ".o",
+	kindExe: "",
+}
+
+func validGOOS(goos string) bool {
+	_, ok := targetTriples[goos]
+	return ok
+}
+
+func validKind(kind string) bool {
+	_, ok := kindExtensions[kind]
+	return ok
+}
+
+// kindFromOutput guesses the artifact kind from the extension of the
+// output path, falling back to LLVM IR.
+func kindFromOutput(output string) string {
+	ext := filepath.Ext(output)
+	if output == "" || output == "-" {
+		return kindLL
+	}
+
+	for kind, kindExt := range kindExtensions {
+		if kindExt != "" && kindExt == ext {
+			return kind
+		}
+	}
+
+	if ext == "" {
+		return kindExe
+	}
+
+	return kindLL
+}
+
+// outputName derives the artifact path from the input path: foo.go becomes
+// foo.ll, foo.s, foo.o or foo.
+func outputName(input, kind string) string {
+	base := "out"
+	if input != "-" {
+		base = strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+	}
+
+	return base + kindExtensions[kind]
+}
+
+func readSource(input string) (string, []byte, error) {
+	if input == "-" {
+		src, err := ioutil.ReadAll(os.Stdin)
+		return "<stdin>", src, err
+	}
+
+	src, err := ioutil.ReadFile(input)
+	return input, src, err
+}
+
+// compileInput reads, parses and lowers the input file into an LLVM module.
+// Diagnostics are printed to stderr in the given format.
+func compileInput(input, goos string, format diag.Format) (*ir.Module, bool) {
+	filename, src, err := readSource(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nano-go: %v\n", err)
+		return nil, false
+	}
+
+	diags := &diag.List{}
+	m := compile(filename, string(src), goos, diags)
+
+	if diags.Len() > 0 {
+		if err := diags.Print(os.Stderr, format); err != nil {
+			fmt.Fprintf(os.Stderr, "nano-go: %v\n", err)
+		}
+	}
+
+	return m, !diags.HasErrors()
+}
+
+func compile(filename, src, goos string, diags *diag.List) (m *ir.Module) {
+	defer func() {
+		if r := recover(); r != nil {
+			diags.Errorf(diag.Position{Filename: filename}, "internal compiler error: %v", r)
+			m = nil
+		}
+	}()
+
+	file := parser.ParseFile(filename, src, diags)
+	if file == nil {
+		// The file has syntax errors, its tree is incomplete.
+		return nil
+	}
+
+	info := check.Check(file, diags)
+	if diags.HasErrors() {
+		return nil
+	}
+
+	goVisitor := visitor.Visitor{
+		GOOS:  goos,
+		Info:  info,
+		Diags: diags,
+	}
+	goVisitor.VisitFile(file)
+
+	goVisitor.Module.SourceFilename = filename
+	goVisitor.Module.TargetTriple = targetTriples[goos]
+
+	return goVisitor.Module
+}
+
+func emitArtifact(m *ir.Module, kind, output string) error {
+	if kind == kindLL {
+		return writeOutput(output, []byte(m.String()))
+	}
+
+	// Everything past textual IR is produced by the LLVM toolchain.
+	tmp, err := ioutil.TempFile("", "nano-go-*.ll")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(m.String()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	switch kind {
+	case kindAsm:
+		cmd = exec.Command("llc", "-filetype=asm", "-o", output, tmp.Name())
+	case kindObj:
+		cmd = exec.Command("llc", "-filetype=obj", "-o", output, tmp.Name())
+	case kindExe:
+		cmd = exec.Command("clang", "-Wno-override-module", "-o", output, tmp.Name())
+	}
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %v", cmd.Args[0], err)
+	}
+
+	return nil
+}
+
+func writeOutput(output string, data []byte) error {
+	if output == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	return ioutil.WriteFile(output, data, 0644)
+}
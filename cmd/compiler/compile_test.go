@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nano-go/diag"
+)
+
+// TestPrograms compiles the programs of testdata, runs their IR with lli and
+// compares what they print with the .out file next to them. A program that
+// panics has the report it prints to stderr in a .err file.
+func TestPrograms(t *testing.T) {
+	lli, err := exec.LookPath("lli")
+	if err != nil {
+		t.Skip("lli not found")
+	}
+
+	files, err := filepath.Glob(filepath.Join("testdata", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		file := file
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".go"), func(t *testing.T) {
+			src, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := ioutil.ReadFile(strings.TrimSuffix(file, ".go") + ".out")
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantErr, err := ioutil.ReadFile(strings.TrimSuffix(file, ".go") + ".err")
+			panics := err == nil
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+
+			diags := &diag.List{}
+			m := compile(file, string(src), defaultGOOS, diags)
+			if diags.HasErrors() {
+				var buf bytes.Buffer
+				diags.Print(&buf, diag.FormatGCC)
+				t.Fatalf("compile errors:\n%s", buf.String())
+			}
+
+			dir, err := ioutil.TempDir("", "nano-go-test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			ll := filepath.Join(dir, "main.ll")
+			if err := ioutil.WriteFile(ll, []byte(m.String()), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var stdout, stderr bytes.Buffer
+			cmd := exec.Command(lli, ll)
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			err = cmd.Run()
+			if panics {
+				// A panic exits with the status 2 of Go.
+				if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 2 {
+					t.Errorf("lli: %v, want exit status 2", err)
+				}
+				if got := stderr.String(); got != string(wantErr) {
+					t.Errorf("stderr:\n%s\nwant:\n%s", got, wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("lli: %v\n%s", err, stderr.String())
+			}
+
+			if got := stdout.String(); got != string(want) {
+				t.Errorf("output:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
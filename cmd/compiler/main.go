@@ -1,37 +1,206 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"github.com/antlr/antlr4/runtime/Go/antlr"
-	"github.com/llvm-project/llvm/bindings/go/llvm"
-	"nano-go/parser"
-	"nano-go/visitor"
+	"os"
+
+	"nano-go/diag"
+)
+
+const usage = `nano-go compiles a subset of Go into LLVM IR.
+
+Usage:
+
+	nano-go <command> [flags] [file.go]
+
+The commands are:
+
+	build   compile a source file into an artifact
+	ir      print the LLVM IR of a source file
+	check   check a source file without emitting anything
+
+The source is read from stdin when the file is omitted or is "-".
+Run "nano-go <command> -h" for the flags of a command.
+`
+
+// Exit codes of the driver.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
 )
 
 func main() {
-	llvm.InitializeAllTargetInfos()
-	llvm.InitializeAllTargets()
-	llvm.InitializeAllTargetMCs()
-	llvm.InitializeAllAsmParsers()
-	llvm.InitializeAllAsmPrinters()
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitUsage)
+	}
+
+	args := os.Args[2:]
+	switch os.Args[1] {
+	case "build":
+		os.Exit(runBuild(args))
+	case "ir":
+		os.Exit(runIR(args))
+	case "check":
+		os.Exit(runCheck(args))
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		os.Exit(exitOK)
+	default:
+		fmt.Fprintf(os.Stderr, "nano-go: unknown command %q\n\n", os.Args[1])
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitUsage)
+	}
+}
 
-	is := antlr.NewInputStream("package main\nfunc main() {\nreturn\n}")
+func runBuild(args []string) int {
+	flags := newFlagSet("build", "[-o output] [-goos os] [-emit kind] [-diag-format format] [file.go]")
+	output := flags.String("o", "", "write the artifact to `file` (derived from the input name by default)")
+	goos := flags.String("goos", defaultGOOS, "target operating `system` (linux or darwin)")
+	emit := flags.String("emit", "", "artifact `kind` to emit: ll, asm, obj or exe (derived from -o by default)")
+	diagFormat := diagFormatFlag(flags)
+	input, ok := parseFlags(flags, args)
+	if !ok {
+		return exitUsage
+	}
 
-	lexer := parser.NewGoLexer(is)
-	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+	kind := *emit
+	if kind == "" {
+		kind = kindFromOutput(*output)
+	}
+	if !validKind(kind) {
+		fmt.Fprintf(os.Stderr, "nano-go: unknown artifact kind %q\n", kind)
+		return exitUsage
+	}
+	if !validGOOS(*goos) {
+		fmt.Fprintf(os.Stderr, "nano-go: unsupported target os %q\n", *goos)
+		return exitUsage
+	}
 
-	p := parser.NewGoParser(stream)
+	m, ok := compileInput(input, *goos, *diagFormat)
+	if !ok {
+		return exitFailure
+	}
 
-	sourceFile := p.SourceFile()
+	out := *output
+	if out == "" {
+		out = outputName(input, kind)
+	}
+	if err := emitArtifact(m, kind, out); err != nil {
+		fmt.Fprintf(os.Stderr, "nano-go: %v\n", err)
+		return exitFailure
+	}
 
-	goVisitor := visitor.Visitor{}
-	goVisitor.VisitSourceFile(sourceFile.(*parser.SourceFileContext))
+	return exitOK
+}
+
+func runIR(args []string) int {
+	flags := newFlagSet("ir", "[-o output] [-goos os] [-diag-format format] [file.go]")
+	output := flags.String("o", "-", "write the IR to `file` instead of stdout")
+	goos := flags.String("goos", defaultGOOS, "target operating `system` (linux or darwin)")
+	diagFormat := diagFormatFlag(flags)
+	input, ok := parseFlags(flags, args)
+	if !ok {
+		return exitUsage
+	}
+	if !validGOOS(*goos) {
+		fmt.Fprintf(os.Stderr, "nano-go: unsupported target os %q\n", *goos)
+		return exitUsage
+	}
 
-	fmt.Println(goVisitor.Module.String())
+	m, ok := compileInput(input, *goos, *diagFormat)
+	if !ok {
+		return exitFailure
+	}
 
-	_, err := llvm.NewMCJITCompiler(goVisitor.Module, llvm.MCJITCompilerOptions{})
+	if err := emitArtifact(m, kindLL, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "nano-go: %v\n", err)
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+func runCheck(args []string) int {
+	flags := newFlagSet("check", "[-goos os] [-diag-format format] [file.go]")
+	goos := flags.String("goos", defaultGOOS, "target operating `system` (linux or darwin)")
+	diagFormat := diagFormatFlag(flags)
+	input, ok := parseFlags(flags, args)
+	if !ok {
+		return exitUsage
+	}
+	if !validGOOS(*goos) {
+		fmt.Fprintf(os.Stderr, "nano-go: unsupported target os %q\n", *goos)
+		return exitUsage
+	}
+
+	if _, ok := compileInput(input, *goos, *diagFormat); !ok {
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+func newFlagSet(name, synopsis string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: nano-go %s %s\n\n", name, synopsis)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// diagFormatFlag defines the -diag-format flag selecting how diagnostics
+// are printed.
+func diagFormatFlag(flags *flag.FlagSet) *diag.Format {
+	format := diag.FormatGCC
+	flags.Var((*formatValue)(&format), "diag-format", "print diagnostics as `format`: gcc or json")
+	return &format
+}
+
+type formatValue diag.Format
+
+func (f *formatValue) String() string {
+	return string(*f)
+}
+
+func (f *formatValue) Set(s string) error {
+	format, err := diag.ParseFormat(s)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		return err
+	}
+
+	*f = formatValue(format)
+	return nil
+}
+
+// parseFlags parses the command line of a command and returns its only
+// positional argument, the input file. Flags may follow the input file, the
+// flag package stops at the first positional argument so the rest of the
+// command line is parsed again after it.
+func parseFlags(flags *flag.FlagSet, args []string) (string, bool) {
+	var inputs []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return "", false
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		inputs = append(inputs, flags.Arg(0))
+		args = flags.Args()[1:]
 	}
+
+	switch len(inputs) {
+	case 0:
+		return "-", true
+	case 1:
+		return inputs[0], true
+	}
+
+	fmt.Fprintln(flags.Output(), "nano-go: expected at most one input file")
+	flags.Usage()
+	return "", false
 }
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs the driver instead of the tests when runDriver starts the
+// test binary.
+func TestMain(m *testing.M) {
+	if os.Getenv("NANO_GO_TEST_DRIVER") == "1" {
+		main()
+	}
+	os.Exit(m.Run())
+}
+
+// runDriver runs the driver with args and stdin, it returns what it printed
+// and its exit status.
+func runDriver(t *testing.T, stdin string, args ...string) (stdout, stderr string, code int) {
+	var out, errOut bytes.Buffer
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "NANO_GO_TEST_DRIVER=1")
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), errOut.String(), code
+}
+
+const (
+	validSrc = `package main
+
+func main() {
+	Printf("%d\n", 1)
+}
+`
+	invalidSrc = `package main
+
+func main() {
+	x := 1
+}
+`
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		args   []string
+		input  string
+		output string
+		ok     bool
+	}{
+		{args: nil, input: "-", ok: true},
+		{args: []string{"-"}, input: "-", ok: true},
+		{args: []string{"a.go"}, input: "a.go", ok: true},
+		{args: []string{"-o", "a.ll", "a.go"}, input: "a.go", output: "a.ll", ok: true},
+		{args: []string{"a.go", "-o", "a.ll"}, input: "a.go", output: "a.ll", ok: true},
+		{args: []string{"a.go", "b.go"}},
+		{args: []string{"a.go", "-o", "a.ll", "b.go"}},
+		{args: []string{"-x", "a.go"}},
+	}
+
+	for _, test := range tests {
+		flags := newFlagSet("test", "[-o output] [file.go]")
+		flags.SetOutput(ioutil.Discard)
+		output := flags.String("o", "", "")
+
+		input, ok := parseFlags(flags, test.args)
+		if ok != test.ok || ok && (input != test.input || *output != test.output) {
+			t.Errorf("parseFlags(%q) = %q, %v with -o %q, want %q, %v with -o %q",
+				test.args, input, ok, *output, test.input, test.ok, test.output)
+		}
+	}
+}
+
+func TestDriver(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		code   int
+		stdout string // a part of the output
+		stderr string // a part of the errors
+	}{
+		{name: "no command", code: exitUsage, stderr: "Usage:"},
+		{name: "unknown command", args: []string{"run"}, code: exitUsage, stderr: `unknown command "run"`},
+		{name: "help", args: []string{"help"}, code: exitOK, stdout: "Usage:"},
+		{name: "check", args: []string{"check"}, stdin: validSrc, code: exitOK},
+		{name: "check errors", args: []string{"check"}, stdin: invalidSrc, code: exitFailure,
+			stderr: "<stdin>:4:2: error: declared and not used: x"},
+		{name: "ir", args: []string{"ir", "-", "-goos", "darwin"}, stdin: validSrc, code: exitOK, stdout: "define i32 @main()"},
+		{name: "unknown flag", args: []string{"ir", "-x"}, code: exitUsage, stderr: "Usage: nano-go ir"},
+		{name: "two files", args: []string{"check", "a.go", "b.go"}, code: exitUsage, stderr: "expected at most one input file"},
+		{name: "missing file", args: []string{"check", "testdata/missing.go"}, code: exitFailure, stderr: "no such file"},
+		{name: "goos", args: []string{"check", "-goos", "windows"}, stdin: validSrc, code: exitUsage,
+			stderr: `unsupported target os "windows"`},
+		{name: "goos after file", args: []string{"build", "-", "-goos=plan9"}, stdin: validSrc, code: exitUsage,
+			stderr: `unsupported target os "plan9"`},
+		{name: "emit", args: []string{"build", "-emit", "wasm"}, stdin: validSrc, code: exitUsage,
+			stderr: `unknown artifact kind "wasm"`},
+		{name: "diag format", args: []string{"check", "-diag-format", "xml"}, stdin: validSrc, code: exitUsage,
+			stderr: "Usage: nano-go check"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			stdout, stderr, code := runDriver(t, test.stdin, test.args...)
+			if code != test.code {
+				t.Errorf("exit status %d, want %d\nstderr:\n%s", code, test.code, stderr)
+			}
+			if !strings.Contains(stdout, test.stdout) {
+				t.Errorf("stdout:\n%s\nwant it to contain %q", stdout, test.stdout)
+			}
+			if !strings.Contains(stderr, test.stderr) {
+				t.Errorf("stderr:\n%s\nwant it to contain %q", stderr, test.stderr)
+			}
+		})
+	}
+}
+
+func TestDriverJSON(t *testing.T) {
+	_, stderr, code := runDriver(t, invalidSrc, "check", "-", "-diag-format=json")
+	if code != exitFailure {
+		t.Errorf("exit status %d, want %d", code, exitFailure)
+	}
+
+	var diags []struct {
+		File     string
+		Line     int
+		Column   int
+		Severity string
+		Message  string
+	}
+	if err := json.Unmarshal([]byte(stderr), &diags); err != nil {
+		t.Fatalf("stderr is not JSON: %v\n%s", err, stderr)
+	}
+	if len(diags) != 1 {
+		t.Fatalf("diagnostics: %+v, want one", diags)
+	}
+	d := diags[0]
+	if d.File != "<stdin>" || d.Line != 4 || d.Column != 2 || d.Severity != "error" || d.Message != "declared and not used: x" {
+		t.Errorf("diagnostic: %+v", d)
+	}
+}
+
+func TestDriverBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nano-go-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The kind of the artifact is derived from the output name.
+	out := filepath.Join(dir, "main.ll")
+	if _, stderr, code := runDriver(t, validSrc, "build", "-o", out); code != exitOK {
+		t.Fatalf("exit status %d\nstderr:\n%s", code, stderr)
+	}
+	ll, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(ll, []byte("define i32 @main()")) {
+		t.Errorf("%s:\n%s\nhas no main", out, ll)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+func main() {
+	// The capacity doubles, gc also rounds it up to its allocation
+	// sizes.
+	var s []int
+	c := -1
+	for i := 0; i < 600; i++ {
+		s = append(s, i)
+		if cap(s) != c {
+			c = cap(s)
+			Printf("%d %d\n", len(s), c)
+		}
+	}
+	sum := 0
+	for _, x := range s {
+		sum += x
+	}
+	Printf("%d\n", sum)
+
+	// Appending several values grows by at least their number.
+	t := append([]int{1, 2}, 3, 4, 5)
+	t = append(t, s[:10]...)
+	Printf("%d %d %d %d\n", len(t), cap(t), t[4], t[14])
+
+	// A slice with room is appended to in place, one without is copied.
+	a := make([]int, 3, 10)
+	b := append(a, 7)
+	b[0] = 1
+	full := a[:3:3]
+	d := append(full, 8)
+	d[1] = 2
+	Printf("%d %d %d %d %d\n", a[0], a[1], b[3], len(d), cap(d))
+
+	var bs []byte
+	for i := 0; i < 40; i++ {
+		bs = append(bs, byte(i))
+	}
+	Printf("%d %d %d\n", len(bs), cap(bs), bs[39])
+	bs = append(bs, "xyz"...)
+	Printf("%d %d\n", len(bs), bs[42])
+}
@@ -0,0 +1,11 @@
+package main
+
+// Print shadows the builtin, calls of Print use it.
+func Print(x int) {
+	Printf("print %d\n", x)
+}
+
+func main() {
+	Print(3)
+	Print(len("four"))
+}
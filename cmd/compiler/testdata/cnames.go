@@ -0,0 +1,28 @@
+package main
+
+// The functions and variables are named like the C functions the runtime
+// calls.
+
+var stdout int = 3
+var printf = 2
+
+func calloc(n int) int {
+	return n + 1
+}
+
+func memmove(a, b int) int {
+	return a - b
+}
+
+func snprintf() int {
+	return 7
+}
+
+func main() {
+	s := make([]int, 3)
+	s = append(s, calloc(4))
+	t := make([]int, 4)
+	copy(t, s)
+	m := map[string]int{"a": memmove(5, 1)}
+	Printf("%d %d %d %d %d %d\n", t[3], m["a"], snprintf(), stdout, printf, len(s))
+}
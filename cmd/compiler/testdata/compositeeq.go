@@ -0,0 +1,47 @@
+package main
+
+type Person struct {
+	name string
+	age  int
+	ok   bool
+}
+
+type Pair struct {
+	_    int
+	a, b float64
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	p := Person{"ann", 30, true}
+	q := Person{"ann", 30, true}
+	r := Person{"bob", 30, true}
+	Printf("%d %d %d\n", b2i(p == q), b2i(p == r), b2i(p != r))
+
+	names := [3]string{"a", "bc", ""}
+	other := names
+	Printf("%d", b2i(names == other))
+	other[2] = "d"
+	Printf(" %d\n", b2i(names == other))
+
+	switch p {
+	case Person{"bob", 30, true}:
+		Printf("bob\n")
+	case Person{name: "ann", age: 30, ok: true}:
+		Printf("ann\n")
+	}
+
+	zero := 0.0
+	x := Pair{a: 1, b: 2}
+	nan := Pair{a: zero / zero}
+	Printf("%d %d\n", b2i(x == Pair{a: 1, b: 2}), b2i(nan == nan))
+
+	people := [2]Person{p, r}
+	Printf("%d\n", b2i(people == [2]Person{q, r}))
+}
@@ -0,0 +1,18 @@
+package main
+
+// sum recurses once per element, the bounds checks of s[i] must not grow
+// its frame much.
+func sum(s []int, i int) int {
+	if i == len(s) {
+		return 0
+	}
+	return s[i] + sum(s, i+1)
+}
+
+func main() {
+	s := make([]int, 20000)
+	for i := range s {
+		s[i] = i % 3
+	}
+	Printf("%d\n", sum(s, 0))
+}
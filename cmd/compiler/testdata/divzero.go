@@ -0,0 +1,11 @@
+package main
+
+func div(a, b int) int {
+	return a / b
+}
+
+func main() {
+	Printf("%d\n", div(7, 2))
+	Printf("%d\n", div(7, 0))
+	Printf("%d\n", div(7, 1))
+}
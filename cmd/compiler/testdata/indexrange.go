@@ -0,0 +1,12 @@
+package main
+
+func get(s []int, i int) int {
+	return s[i]
+}
+
+func main() {
+	s := []int{1, 2, 3}
+	for i := 0; i <= 3; i++ {
+		Printf("%d\n", get(s, i))
+	}
+}
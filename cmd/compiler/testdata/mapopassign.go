@@ -0,0 +1,22 @@
+package main
+
+var m map[int]int
+
+// grow adds enough entries to m for its table to be rebuilt.
+func grow() int {
+	for i := 0; i < 2000; i++ {
+		m[i] = 0
+	}
+	return 5
+}
+
+func main() {
+	m = make(map[int]int)
+	m[0] += grow()
+	Printf("%d %d\n", m[0], len(m))
+
+	m = map[int]int{}
+	(m[1]) -= grow()
+	m[2] *= 3
+	Printf("%d %d %d\n", m[1], m[2], len(m))
+}
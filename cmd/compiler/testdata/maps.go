@@ -0,0 +1,37 @@
+package main
+
+func main() {
+	// The table is rebuilt several times while growing.
+	m := make(map[int]int)
+	for i := 0; i < 5000; i++ {
+		m[i] = i * i
+	}
+	for i := 0; i < 5000; i += 2 {
+		delete(m, i)
+	}
+	sum := 0
+	for k, v := range m {
+		sum += v - k*k
+	}
+	v, ok := m[4999]
+	_, gone := m[4998]
+	Printf("%d %d %d\n", len(m), sum, v)
+	if ok && !gone {
+		Printf("ok\n")
+	}
+
+	words := map[string]int{}
+	for _, w := range []string{"a", "bb", "a", "ccc", "bb", "a"} {
+		words[w]++
+	}
+	Printf("%d %d %d %d\n", len(words), words["a"], words["bb"], words["ccc"])
+
+	cells := map[[2]int]float64{}
+	cells[[2]int{1, 2}] = 0.5
+	cells[[2]int{2, 1}] += 2
+	cells[[2]int{1, 2}] *= 3
+	Printf("%d %.1f %.1f\n", len(cells), cells[[2]int{1, 2}], cells[[2]int{2, 1}])
+
+	var nilMap map[int]int
+	Printf("%d %d\n", len(nilMap), nilMap[3])
+}
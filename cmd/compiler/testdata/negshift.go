@@ -0,0 +1,9 @@
+package main
+
+func main() {
+	n := 3
+	for n >= -1 {
+		Printf("%d\n", 1<<n)
+		n--
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var global []float64 = nil
+
+func lookup(m map[string]int) int {
+	if m == nil {
+		return -1
+	}
+	return m["a"]
+}
+
+func none() []int {
+	return nil
+}
+
+func main() {
+	var s []int = nil
+	Printf("%d %d %d\n", b2i(s == nil), b2i(nil != s), len(s))
+	s = append(s, 1)
+	Printf("%d %d\n", b2i(s == nil), len(s))
+	s = nil
+	Printf("%d %d\n", b2i(s == nil), cap(s))
+	empty := make([]int, 0)
+	Printf("%d %d\n", b2i(empty == nil), b2i(s[:0] == nil))
+
+	m := map[string]int{"a": 3}
+	Printf("%d %d\n", lookup(m), b2i(m != nil))
+	m = nil
+	Printf("%d %d %d\n", lookup(m), len(m), m["b"])
+	Printf("%d %d\n", lookup(map[string]int(nil)), len([]int(nil)))
+	Printf("%d\n", b2i(global == nil))
+
+	rows := [][]int{nil, {1}}
+	Printf("%d %d %d\n", b2i(rows[0] == nil), b2i(rows[1] == nil), b2i(none() == nil))
+
+	switch rows[0] {
+	case nil:
+		Printf("nil row\n")
+	default:
+		Printf("row\n")
+	}
+}
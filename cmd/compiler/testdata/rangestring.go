@@ -0,0 +1,26 @@
+package main
+
+func main() {
+	for i, r := range "aé€😀" {
+		Printf("%d %d\n", i, r)
+	}
+
+	// Invalid bytes give the replacement character and advance by one.
+	for i, r := range "a\xffb\xe2\x82c\xf0\x9f\x98" {
+		Printf("%d %d\n", i, r)
+	}
+
+	// Encodings that are too long or surrogates are invalid too.
+	for i, r := range "\xc0\x80\xed\xa0\x80\xf4\x90\x80\x80" {
+		Printf("%d %d\n", i, r)
+	}
+
+	n := 0
+	for i := range "h€llo" {
+		n += i
+	}
+	for range "" {
+		n = -1
+	}
+	Printf("%d\n", n)
+}
@@ -0,0 +1,30 @@
+package main
+
+func count() uint {
+	return 7
+}
+
+func main() {
+	var s uint = 7
+	var u uint8 = 1 << s
+	var x int64 = 1 << (s + 40) >> 30
+	var i int32 = -1 << s
+	Printf("%d %d %d\n", u, x, i)
+
+	// The operand gets its type from the other operand.
+	var b uint8 = 200
+	Printf("%d\n", b+1<<s)
+	Printf("%d\n", uint16(1<<s)+uint16(1<<count()))
+
+	// Without a context, it gets its default type.
+	y := 1 << (s + 40) >> 30
+	Printf("%d\n", y)
+	Printf("%d\n", 1<<s)
+	if 1<<s == 128 {
+		var j int = 1.0 << s
+		Printf("%d\n", j)
+	}
+	var a [200]int
+	a[1<<s] = 3
+	Printf("%d\n", a[128])
+}
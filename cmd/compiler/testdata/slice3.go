@@ -0,0 +1,35 @@
+package main
+
+func main() {
+	var a [8]int
+	for i := range a {
+		a[i] = i * 10
+	}
+
+	s := a[2:4:6]
+	Printf("%d %d %d %d\n", len(s), cap(s), s[0], s[1])
+	t := s[1:4]
+	Printf("%d %d %d\n", len(t), cap(t), t[2])
+	t[2] = -1
+	Printf("%d\n", a[5])
+
+	// The capacity limits what append may overwrite.
+	u := append(s, 1, 2, 3)
+	u[0] = 99
+	Printf("%d %d %d\n", a[2], a[6], u[4])
+
+	v := s[:0:0]
+	Printf("%d %d\n", len(v), cap(v))
+	w := a[:]
+	x := w[3:3:8]
+	Printf("%d %d\n", len(x), cap(x))
+
+	lo, hi, max := 1, 3, 7
+	y := w[lo:hi:max]
+	Printf("%d %d %d\n", len(y), cap(y), y[1])
+	z := y[:cap(y)]
+	Printf("%d %d\n", len(z), z[5])
+
+	str := "hello, world"
+	Printf("%d %d\n", len(str[7:]), str[7:][0])
+}
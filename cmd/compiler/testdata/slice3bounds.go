@@ -0,0 +1,12 @@
+package main
+
+func main() {
+	var a [4]int
+	s := a[:]
+	lo, hi := 1, 2
+	for i := 0; i < 3; i++ {
+		t := s[lo:hi:4]
+		Printf("%d %d\n", len(t), cap(t))
+		lo += 2
+	}
+}
@@ -0,0 +1,9 @@
+package main
+
+func main() {
+	s := make([]int, 2, 5)
+	for n := 3; n <= 6; n++ {
+		t := s[1:n]
+		Printf("%d %d\n", len(t), cap(t))
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func kind(s string) int {
+	switch s {
+	case "":
+		return 0
+	case "a", "b":
+		return 1
+	case "hello":
+		return 2
+	}
+	return -1
+}
+
+func main() {
+	w := "hello world"
+	s := w[:3]
+	t := w[:5]
+	Printf("%d %d %d %d\n", b2i(t == "hello"), b2i(t != "hello"), b2i(s == t), b2i("hel" == s))
+	Printf("%d %d %d %d %d\n", kind(""), kind("a"), kind("b"), kind(t), kind(s))
+
+	var u string
+	Printf("%d %d\n", b2i(u == ""), b2i(u == w[5:5]))
+}
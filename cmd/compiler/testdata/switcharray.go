@@ -0,0 +1,33 @@
+package main
+
+func classify(a [2]int) int {
+	switch a {
+	case [2]int{1, 2}:
+		return 1
+	case [2]int{2, 1}, [2]int{3, 3}:
+		return 2
+	}
+	return 0
+}
+
+func main() {
+	Printf("%d %d %d %d\n", classify([2]int{1, 2}), classify([2]int{2, 1}), classify([2]int{3, 3}), classify([2]int{}))
+
+	grid := [2][2]float64{{0.5, 1}, {2, 4}}
+	switch grid {
+	case [2][2]float64{}:
+		Printf("zero\n")
+	case [2][2]float64{{0.5, 1}, {2, 4}}:
+		Printf("grid\n")
+	default:
+		Printf("other\n")
+	}
+
+	x := 1.5
+	switch x {
+	case 1:
+		Printf("one\n")
+	case 1.5:
+		Printf("one and a half\n")
+	}
+}
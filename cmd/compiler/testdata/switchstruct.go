@@ -0,0 +1,32 @@
+package main
+
+type Pt struct {
+	x, y int
+}
+
+type Seg struct {
+	from, to Pt
+	open     bool
+}
+
+func quadrant(p Pt) int {
+	switch p {
+	case Pt{}:
+		return 0
+	case Pt{1, 2}, Pt{x: 2, y: 1}:
+		return 1
+	}
+	return -1
+}
+
+func main() {
+	Printf("%d %d %d %d\n", quadrant(Pt{}), quadrant(Pt{1, 2}), quadrant(Pt{2, 1}), quadrant(Pt{5, 5}))
+
+	s := Seg{Pt{1, 1}, Pt{2, 2}, true}
+	switch s {
+	case Seg{from: Pt{1, 1}, to: Pt{2, 2}}:
+		Printf("closed\n")
+	case Seg{Pt{1, 1}, Pt{2, 2}, true}:
+		Printf("open\n")
+	}
+}
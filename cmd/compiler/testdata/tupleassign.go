@@ -0,0 +1,34 @@
+package main
+
+var calls int
+
+func next() int {
+	calls++
+	return calls
+}
+
+func main() {
+	var a [3]int
+	i := 0
+	i, a[i] = 2, 7
+	Printf("%d %d %d %d\n", i, a[0], a[1], a[2])
+
+	s := []int{1, 2, 3}
+	j := 1
+	s[j], j, s[j] = 10, 2, 20
+	Printf("%d %d %d %d\n", j, s[0], s[1], s[2])
+
+	// The operands on the left are evaluated before the values.
+	var b [4]int
+	b[next()], b[next()] = next(), next()
+	Printf("%d %d %d %d\n", b[0], b[1], b[2], b[3])
+
+	m := map[int]int{}
+	k := 1
+	k, m[k] = 5, k
+	Printf("%d %d %d\n", k, m[1], len(m))
+
+	x, y := 1, 2
+	x, y = y, x
+	Printf("%d %d\n", x, y)
+}
@@ -0,0 +1,136 @@
+package diag
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Note:
+		return "note"
+	}
+
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Position is a location in a source file. Line and Column are 1-based,
+// a zero Line means the position is unknown.
+type Position struct {
+	Filename string
+	Line     int
+	Column   int
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	filename := p.Filename
+	if filename == "" {
+		filename = "<input>"
+	}
+
+	if !p.IsValid() {
+		return filename
+	}
+
+	return fmt.Sprintf("%s:%d:%d", filename, p.Line, p.Column)
+}
+
+// TokenPosition converts the position of an ANTLR token, whose columns are
+// 0-based, into a Position.
+func TokenPosition(filename string, token antlr.Token) Position {
+	if token == nil {
+		return Position{Filename: filename}
+	}
+
+	return Position{
+		Filename: filename,
+		Line:     token.GetLine(),
+		Column:   token.GetColumn() + 1,
+	}
+}
+
+type Diagnostic struct {
+	Pos      Position
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Pos, d.Severity, d.Message)
+}
+
+// List collects the diagnostics of a compilation. The zero value is ready
+// to use.
+type List struct {
+	diags  []Diagnostic
+	errors int
+}
+
+func (l *List) Add(d Diagnostic) {
+	if d.Severity == Error {
+		l.errors++
+	}
+	l.diags = append(l.diags, d)
+}
+
+func (l *List) Errorf(pos Position, format string, args ...interface{}) {
+	l.Add(Diagnostic{Pos: pos, Severity: Error, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *List) Warningf(pos Position, format string, args ...interface{}) {
+	l.Add(Diagnostic{Pos: pos, Severity: Warning, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *List) Notef(pos Position, format string, args ...interface{}) {
+	l.Add(Diagnostic{Pos: pos, Severity: Note, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *List) ErrorCount() int {
+	return l.errors
+}
+
+func (l *List) HasErrors() bool {
+	return l.errors > 0
+}
+
+func (l *List) Len() int {
+	return len(l.diags)
+}
+
+// Diagnostics returns the collected diagnostics ordered by position.
+// Diagnostics at the same position keep the order they were reported in.
+func (l *List) Diagnostics() []Diagnostic {
+	sorted := make([]Diagnostic, len(l.diags))
+	copy(sorted, l.diags)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Pos, sorted[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return sorted
+}
@@ -0,0 +1,77 @@
+package diag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type Format string
+
+const (
+	// FormatGCC prints one "file:line:column: severity: message" line per
+	// diagnostic.
+	FormatGCC Format = "gcc"
+	// FormatJSON prints a JSON array of diagnostic objects.
+	FormatJSON Format = "json"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatGCC, FormatJSON:
+		return Format(s), nil
+	}
+
+	return "", fmt.Errorf("unknown diagnostics format %q", s)
+}
+
+type jsonDiagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Print writes all collected diagnostics to w in the given format.
+func (l *List) Print(w io.Writer, format Format) error {
+	diags := l.Diagnostics()
+
+	switch format {
+	case FormatJSON:
+		out := make([]jsonDiagnostic, 0, len(diags))
+		for _, d := range diags {
+			out = append(out, jsonDiagnostic{
+				File:     d.Pos.Filename,
+				Line:     d.Pos.Line,
+				Column:   d.Pos.Column,
+				Severity: d.Severity.String(),
+				Message:  d.Message,
+			})
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	default:
+		for _, d := range diags {
+			if _, err := fmt.Fprintln(w, d); err != nil {
+				return err
+			}
+		}
+
+		if l.errors > 0 {
+			_, err := fmt.Fprintf(w, "%d %s generated.\n", l.errors, plural(l.errors, "error", "errors"))
+			return err
+		}
+	}
+
+	return nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -4,6 +4,5 @@ go 1.14
 
 require (
 	github.com/antlr/antlr4 v0.0.0-20181218183524-be58ebffde8e
-	github.com/llvm-mirror/llvm v0.0.0-20191022153947-2c4ca6832fa6
-	github.com/llvm-project/llvm v0.0.0-20191022153947-2c4ca6832fa6
+	github.com/llir/llvm v0.3.2
 )
@@ -0,0 +1,1079 @@
+package parser
+
+import (
+	"go/token"
+
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+
+	"nano-go/ast"
+	"nano-go/diag"
+)
+
+// Tokens of the binary, unary and assignment operators.
+var (
+	binaryOps = map[int]token.Token{
+		GoParserSTAR:              token.MUL,
+		GoParserDIV:               token.QUO,
+		GoParserMOD:               token.REM,
+		GoParserLSHIFT:            token.SHL,
+		GoParserRSHIFT:            token.SHR,
+		GoParserAMPERSAND:         token.AND,
+		GoParserBIT_CLEAR:         token.AND_NOT,
+		GoParserPLUS:              token.ADD,
+		GoParserMINUS:             token.SUB,
+		GoParserOR:                token.OR,
+		GoParserCARET:             token.XOR,
+		GoParserEQUALS:            token.EQL,
+		GoParserNOT_EQUALS:        token.NEQ,
+		GoParserLESS:              token.LSS,
+		GoParserLESS_OR_EQUALS:    token.LEQ,
+		GoParserGREATER:           token.GTR,
+		GoParserGREATER_OR_EQUALS: token.GEQ,
+		GoParserLOGICAL_AND:       token.LAND,
+		GoParserLOGICAL_OR:        token.LOR,
+	}
+
+	unaryOps = map[int]token.Token{
+		GoParserPLUS:        token.ADD,
+		GoParserMINUS:       token.SUB,
+		GoParserEXCLAMATION: token.NOT,
+		GoParserCARET:       token.XOR,
+		GoParserAMPERSAND:   token.AND,
+		GoParserRECEIVE:     token.ARROW,
+	}
+
+	assignOps = map[token.Token]token.Token{
+		token.ADD:     token.ADD_ASSIGN,
+		token.SUB:     token.SUB_ASSIGN,
+		token.MUL:     token.MUL_ASSIGN,
+		token.QUO:     token.QUO_ASSIGN,
+		token.REM:     token.REM_ASSIGN,
+		token.AND:     token.AND_ASSIGN,
+		token.OR:      token.OR_ASSIGN,
+		token.XOR:     token.XOR_ASSIGN,
+		token.SHL:     token.SHL_ASSIGN,
+		token.SHR:     token.SHR_ASSIGN,
+		token.AND_NOT: token.AND_NOT_ASSIGN,
+	}
+)
+
+// BuildFile lowers the parse tree of a file without syntax errors into an
+// ast.File. Constructs the AST cannot represent are reported to diags and
+// replaced by Bad nodes.
+func BuildFile(filename string, ctx *SourceFileContext, diags *diag.List) *ast.File {
+	b := &builder{filename: filename, diags: diags}
+	return b.file(ctx)
+}
+
+type builder struct {
+	filename string
+	diags    *diag.List
+}
+
+func (b *builder) pos(ctx antlr.ParserRuleContext) diag.Position {
+	return diag.TokenPosition(b.filename, ctx.GetStart())
+}
+
+func (b *builder) tokenPos(node antlr.TerminalNode) diag.Position {
+	return diag.TokenPosition(b.filename, node.GetSymbol())
+}
+
+func (b *builder) errorf(ctx antlr.ParserRuleContext, format string, args ...interface{}) {
+	b.diags.Errorf(b.pos(ctx), format, args...)
+}
+
+func (b *builder) ident(node antlr.TerminalNode) *ast.Ident {
+	return &ast.Ident{NamePos: b.tokenPos(node), Name: node.GetText()}
+}
+
+func (b *builder) identifierList(ctx IIdentifierListContext) []*ast.Ident {
+	var idents []*ast.Ident
+	for _, node := range ctx.(*IdentifierListContext).AllIDENTIFIER() {
+		idents = append(idents, b.ident(node))
+	}
+	return idents
+}
+
+// ----------------------------------------------------------------------------
+// Declarations
+
+func (b *builder) file(ctx *SourceFileContext) *ast.File {
+	pkg := ctx.PackageClause().(*PackageClauseContext)
+	f := &ast.File{
+		Filename: b.filename,
+		Package:  b.tokenPos(pkg.PACKAGE()),
+		Name:     b.ident(pkg.IDENTIFIER()),
+	}
+
+	// Declarations are kept in source order.
+	for _, child := range ctx.GetChildren() {
+		switch c := child.(type) {
+		case *ImportDeclContext:
+			decl := b.importDecl(c)
+			for _, spec := range decl.Specs {
+				f.Imports = append(f.Imports, spec.(*ast.ImportSpec))
+			}
+			f.Decls = append(f.Decls, decl)
+		case *FunctionDeclContext:
+			f.Decls = append(f.Decls, b.functionDecl(c))
+		case *MethodDeclContext:
+			f.Decls = append(f.Decls, b.methodDecl(c))
+		case *DeclarationContext:
+			f.Decls = append(f.Decls, b.declaration(c))
+		}
+	}
+
+	return f
+}
+
+func (b *builder) importDecl(ctx *ImportDeclContext) *ast.GenDecl {
+	decl := &ast.GenDecl{TokPos: b.tokenPos(ctx.IMPORT()), Tok: token.IMPORT}
+	for _, specCtx := range ctx.AllImportSpec() {
+		spec := specCtx.(*ImportSpecContext)
+		importSpec := &ast.ImportSpec{
+			Path: b.string_(spec.ImportPath().(*ImportPathContext).String_()),
+		}
+		if spec.DOT() != nil {
+			importSpec.Name = b.ident(spec.DOT())
+		} else if spec.IDENTIFIER() != nil {
+			importSpec.Name = b.ident(spec.IDENTIFIER())
+		}
+		decl.Specs = append(decl.Specs, importSpec)
+	}
+
+	return decl
+}
+
+func (b *builder) declaration(ctx IDeclarationContext) ast.Decl {
+	declCtx := ctx.(*DeclarationContext)
+
+	if c := declCtx.ConstDecl(); c != nil {
+		constDecl := c.(*ConstDeclContext)
+		decl := &ast.GenDecl{TokPos: b.tokenPos(constDecl.CONST()), Tok: token.CONST}
+		for i, specCtx := range constDecl.AllConstSpec() {
+			spec := specCtx.(*ConstSpecContext)
+			decl.Specs = append(decl.Specs, b.valueSpec(spec.IdentifierList(), spec.Type_(), spec.ExpressionList(), i))
+		}
+		return decl
+	}
+
+	if c := declCtx.VarDecl(); c != nil {
+		varDecl := c.(*VarDeclContext)
+		decl := &ast.GenDecl{TokPos: b.tokenPos(varDecl.VAR()), Tok: token.VAR}
+		for _, specCtx := range varDecl.AllVarSpec() {
+			spec := specCtx.(*VarSpecContext)
+			decl.Specs = append(decl.Specs, b.valueSpec(spec.IdentifierList(), spec.Type_(), spec.ExpressionList(), 0))
+		}
+		return decl
+	}
+
+	if c := declCtx.TypeDecl(); c != nil {
+		typeDecl := c.(*TypeDeclContext)
+		decl := &ast.GenDecl{TokPos: b.tokenPos(typeDecl.TYPE()), Tok: token.TYPE}
+		for _, specCtx := range typeDecl.AllTypeSpec() {
+			spec := specCtx.(*TypeSpecContext)
+			typeSpec := &ast.TypeSpec{
+				Name: b.ident(spec.IDENTIFIER()),
+				Type: b.type_(spec.Type_()),
+			}
+			if spec.ASSIGN() != nil {
+				typeSpec.Assign = b.tokenPos(spec.ASSIGN())
+			}
+			decl.Specs = append(decl.Specs, typeSpec)
+		}
+		return decl
+	}
+
+	b.errorf(declCtx, "unsupported declaration")
+	return &ast.BadDecl{From: b.pos(declCtx)}
+}
+
+func (b *builder) valueSpec(names IIdentifierListContext, typ IType_Context, values IExpressionListContext, iota int) *ast.ValueSpec {
+	spec := &ast.ValueSpec{
+		Names: b.identifierList(names),
+		Iota:  iota,
+	}
+	if typ != nil {
+		spec.Type = b.type_(typ)
+	}
+	if values != nil {
+		spec.Values = b.expressionList(values)
+	}
+
+	return spec
+}
+
+func (b *builder) functionDecl(ctx *FunctionDeclContext) *ast.FuncDecl {
+	decl := &ast.FuncDecl{
+		Name: b.ident(ctx.IDENTIFIER()),
+		Type: b.signature(b.tokenPos(ctx.FUNC()), ctx.Signature()),
+	}
+	if ctx.Block() != nil {
+		decl.Body = b.block(ctx.Block())
+	}
+
+	return decl
+}
+
+func (b *builder) methodDecl(ctx *MethodDeclContext) *ast.FuncDecl {
+	decl := &ast.FuncDecl{
+		Recv: b.parameters(ctx.Receiver().(*ReceiverContext).Parameters()),
+		Name: b.ident(ctx.IDENTIFIER()),
+		Type: b.signature(b.tokenPos(ctx.FUNC()), ctx.Signature()),
+	}
+	if ctx.Block() != nil {
+		decl.Body = b.block(ctx.Block())
+	}
+
+	return decl
+}
+
+func (b *builder) signature(funcPos diag.Position, ctx ISignatureContext) *ast.FuncType {
+	signature := ctx.(*SignatureContext)
+	funcType := &ast.FuncType{
+		Func:   funcPos,
+		Params: b.parameters(signature.Parameters()),
+	}
+
+	if resultCtx := signature.Result(); resultCtx != nil {
+		funcType.Results = b.result(resultCtx)
+	}
+
+	return funcType
+}
+
+func (b *builder) result(ctx IResultContext) *ast.FieldList {
+	result := ctx.(*ResultContext)
+	if params := result.Parameters(); params != nil {
+		return b.parameters(params)
+	}
+
+	typ := b.type_(result.Type_())
+	return &ast.FieldList{
+		Opening: typ.Pos(),
+		List:    []*ast.Field{{Type: typ}},
+	}
+}
+
+// parameters builds a parameter list. The grammar cannot tell the names of
+// func(a, b int) from the types of func(int, string), so in a list with any
+// named parameter the lone identifiers are regrouped as names of the next
+// declared type, like go/parser does.
+func (b *builder) parameters(ctx IParametersContext) *ast.FieldList {
+	parameters := ctx.(*ParametersContext)
+	list := &ast.FieldList{Opening: b.tokenPos(parameters.L_PAREN())}
+
+	named := false
+	for _, declCtx := range parameters.AllParameterDecl() {
+		if declCtx.(*ParameterDeclContext).IdentifierList() != nil {
+			named = true
+		}
+	}
+
+	var pending []*ast.Ident
+	for _, declCtx := range parameters.AllParameterDecl() {
+		decl := declCtx.(*ParameterDeclContext)
+
+		typ := b.type_(decl.Type_())
+		if decl.ELLIPSIS() != nil {
+			typ = &ast.Ellipsis{Ellipsis: b.tokenPos(decl.ELLIPSIS()), Elt: typ}
+		}
+
+		if !named {
+			list.List = append(list.List, &ast.Field{Type: typ})
+			continue
+		}
+
+		if decl.IdentifierList() == nil {
+			if name, ok := typ.(*ast.Ident); ok {
+				pending = append(pending, name)
+				continue
+			}
+			b.errorf(decl, "mixed named and unnamed parameters")
+			continue
+		}
+
+		names := append(pending, b.identifierList(decl.IdentifierList())...)
+		pending = nil
+		list.List = append(list.List, &ast.Field{Names: names, Type: typ})
+	}
+
+	if len(pending) > 0 {
+		b.diags.Errorf(pending[0].Pos(), "mixed named and unnamed parameters")
+	}
+
+	return list
+}
+
+// ----------------------------------------------------------------------------
+// Types
+
+func (b *builder) type_(ctx IType_Context) ast.Expr {
+	typeCtx := ctx.(*Type_Context)
+
+	if name := typeCtx.TypeName(); name != nil {
+		return b.typeName(name)
+	}
+
+	if lit := typeCtx.TypeLit(); lit != nil {
+		return b.typeLit(lit)
+	}
+
+	return b.type_(typeCtx.Type_())
+}
+
+func (b *builder) typeName(ctx ITypeNameContext) ast.Expr {
+	typeName := ctx.(*TypeNameContext)
+
+	if qualified := typeName.QualifiedIdent(); qualified != nil {
+		idents := qualified.(*QualifiedIdentContext).AllIDENTIFIER()
+		return &ast.SelectorExpr{X: b.ident(idents[0]), Sel: b.ident(idents[1])}
+	}
+
+	return b.ident(typeName.IDENTIFIER())
+}
+
+func (b *builder) nonNamedType(ctx INonNamedTypeContext) ast.Expr {
+	nonNamed := ctx.(*NonNamedTypeContext)
+
+	if lit := nonNamed.TypeLit(); lit != nil {
+		return b.typeLit(lit)
+	}
+
+	return b.nonNamedType(nonNamed.NonNamedType())
+}
+
+func (b *builder) elementType(ctx IElementTypeContext) ast.Expr {
+	return b.type_(ctx.(*ElementTypeContext).Type_())
+}
+
+func (b *builder) typeLit(ctx ITypeLitContext) ast.Expr {
+	typeLit := ctx.(*TypeLitContext)
+
+	switch {
+	case typeLit.ArrayType() != nil:
+		return b.arrayType(typeLit.ArrayType())
+
+	case typeLit.StructType() != nil:
+		return b.structType(typeLit.StructType())
+
+	case typeLit.PointerType() != nil:
+		pointer := typeLit.PointerType().(*PointerTypeContext)
+		return &ast.StarExpr{Star: b.tokenPos(pointer.STAR()), X: b.type_(pointer.Type_())}
+
+	case typeLit.FunctionType() != nil:
+		function := typeLit.FunctionType().(*FunctionTypeContext)
+		return b.signature(b.tokenPos(function.FUNC()), function.Signature())
+
+	case typeLit.InterfaceType() != nil:
+		return b.interfaceType(typeLit.InterfaceType())
+
+	case typeLit.SliceType() != nil:
+		slice := typeLit.SliceType().(*SliceTypeContext)
+		return &ast.ArrayType{Lbrack: b.tokenPos(slice.L_BRACKET()), Elt: b.elementType(slice.ElementType())}
+
+	case typeLit.MapType() != nil:
+		mapType := typeLit.MapType().(*MapTypeContext)
+		return &ast.MapType{
+			Map:   b.tokenPos(mapType.MAP()),
+			Key:   b.type_(mapType.Type_()),
+			Value: b.elementType(mapType.ElementType()),
+		}
+
+	case typeLit.ChannelType() != nil:
+		return b.channelType(typeLit.ChannelType())
+	}
+
+	b.errorf(typeLit, "unsupported type: %s", typeLit.GetText())
+	return &ast.BadExpr{From: b.pos(typeLit)}
+}
+
+func (b *builder) arrayType(ctx IArrayTypeContext) *ast.ArrayType {
+	array := ctx.(*ArrayTypeContext)
+	return &ast.ArrayType{
+		Lbrack: b.tokenPos(array.L_BRACKET()),
+		Len:    b.expression(array.ArrayLength().(*ArrayLengthContext).Expression()),
+		Elt:    b.elementType(array.ElementType()),
+	}
+}
+
+func (b *builder) structType(ctx IStructTypeContext) *ast.StructType {
+	structType := ctx.(*StructTypeContext)
+	fields := &ast.FieldList{Opening: b.tokenPos(structType.L_CURLY())}
+
+	for _, fieldCtx := range structType.AllFieldDecl() {
+		fieldDecl := fieldCtx.(*FieldDeclContext)
+		field := &ast.Field{}
+
+		if embedded := fieldDecl.EmbeddedField(); embedded != nil {
+			embeddedField := embedded.(*EmbeddedFieldContext)
+			field.Type = b.typeName(embeddedField.TypeName())
+			if embeddedField.STAR() != nil {
+				field.Type = &ast.StarExpr{Star: b.tokenPos(embeddedField.STAR()), X: field.Type}
+			}
+		} else {
+			field.Names = b.identifierList(fieldDecl.IdentifierList())
+			field.Type = b.type_(fieldDecl.Type_())
+		}
+
+		if tag := fieldDecl.GetTag(); tag != nil {
+			field.Tag = b.string_(tag)
+		}
+
+		fields.List = append(fields.List, field)
+	}
+
+	return &ast.StructType{Struct: b.tokenPos(structType.STRUCT()), Fields: fields}
+}
+
+func (b *builder) interfaceType(ctx IInterfaceTypeContext) *ast.InterfaceType {
+	interfaceType := ctx.(*InterfaceTypeContext)
+	methods := &ast.FieldList{Opening: b.tokenPos(interfaceType.L_CURLY())}
+
+	for _, child := range interfaceType.GetChildren() {
+		switch c := child.(type) {
+		case *MethodSpecContext:
+			funcType := &ast.FuncType{
+				Func:   b.pos(c),
+				Params: b.parameters(c.Parameters()),
+			}
+			if c.Result() != nil {
+				funcType.Results = b.result(c.Result())
+			}
+			methods.List = append(methods.List, &ast.Field{
+				Names: []*ast.Ident{b.ident(c.IDENTIFIER())},
+				Type:  funcType,
+			})
+		case *TypeNameContext:
+			methods.List = append(methods.List, &ast.Field{Type: b.typeName(c)})
+		}
+	}
+
+	return &ast.InterfaceType{Interface: b.tokenPos(interfaceType.INTERFACE()), Methods: methods}
+}
+
+func (b *builder) channelType(ctx IChannelTypeContext) *ast.ChanType {
+	channel := ctx.(*ChannelTypeContext)
+	chanType := &ast.ChanType{
+		Begin: b.pos(channel),
+		Dir:   ast.SEND | ast.RECV,
+		Value: b.elementType(channel.ElementType()),
+	}
+
+	if channel.RECEIVE() != nil {
+		if channel.RECEIVE().GetSymbol().GetTokenIndex() < channel.CHAN().GetSymbol().GetTokenIndex() {
+			chanType.Dir = ast.RECV
+		} else {
+			chanType.Dir = ast.SEND
+		}
+	}
+
+	return chanType
+}
+
+// ----------------------------------------------------------------------------
+// Statements
+
+func (b *builder) block(ctx IBlockContext) *ast.BlockStmt {
+	block := ctx.(*BlockContext)
+	return &ast.BlockStmt{
+		Lbrace: b.tokenPos(block.L_CURLY()),
+		List:   b.statementList(block.StatementList()),
+		Rbrace: b.tokenPos(block.R_CURLY()),
+	}
+}
+
+func (b *builder) statementList(ctx IStatementListContext) []ast.Stmt {
+	if ctx == nil {
+		return nil
+	}
+
+	var list []ast.Stmt
+	for _, stmtCtx := range ctx.(*StatementListContext).AllStatement() {
+		list = append(list, b.statement(stmtCtx))
+	}
+
+	return list
+}
+
+func (b *builder) statement(ctx IStatementContext) ast.Stmt {
+	stmt := ctx.(*StatementContext)
+
+	switch {
+	case stmt.Declaration() != nil:
+		return &ast.DeclStmt{Decl: b.declaration(stmt.Declaration())}
+
+	case stmt.LabeledStmt() != nil:
+		labeled := stmt.LabeledStmt().(*LabeledStmtContext)
+		labeledStmt := &ast.LabeledStmt{
+			Label: b.ident(labeled.IDENTIFIER()),
+			Colon: b.tokenPos(labeled.COLON()),
+		}
+		if labeled.Statement() != nil {
+			labeledStmt.Stmt = b.statement(labeled.Statement())
+		} else {
+			labeledStmt.Stmt = &ast.EmptyStmt{Semicolon: labeledStmt.Colon}
+		}
+		return labeledStmt
+
+	case stmt.SimpleStmt() != nil:
+		return b.simpleStmt(stmt.SimpleStmt())
+
+	case stmt.GoStmt() != nil:
+		goStmt := stmt.GoStmt().(*GoStmtContext)
+		call, ok := b.callExpr(goStmt.Expression(), "go")
+		if !ok {
+			return &ast.BadStmt{From: b.pos(goStmt)}
+		}
+		return &ast.GoStmt{Go: b.tokenPos(goStmt.GO()), Call: call}
+
+	case stmt.ReturnStmt() != nil:
+		ret := stmt.ReturnStmt().(*ReturnStmtContext)
+		returnStmt := &ast.ReturnStmt{Return: b.tokenPos(ret.RETURN())}
+		if ret.ExpressionList() != nil {
+			returnStmt.Results = b.expressionList(ret.ExpressionList())
+		}
+		return returnStmt
+
+	case stmt.BreakStmt() != nil:
+		branch := stmt.BreakStmt().(*BreakStmtContext)
+		return b.branchStmt(branch.BREAK(), token.BREAK, branch.IDENTIFIER())
+
+	case stmt.ContinueStmt() != nil:
+		branch := stmt.ContinueStmt().(*ContinueStmtContext)
+		return b.branchStmt(branch.CONTINUE(), token.CONTINUE, branch.IDENTIFIER())
+
+	case stmt.GotoStmt() != nil:
+		branch := stmt.GotoStmt().(*GotoStmtContext)
+		return b.branchStmt(branch.GOTO(), token.GOTO, branch.IDENTIFIER())
+
+	case stmt.FallthroughStmt() != nil:
+		branch := stmt.FallthroughStmt().(*FallthroughStmtContext)
+		return b.branchStmt(branch.FALLTHROUGH(), token.FALLTHROUGH, nil)
+
+	case stmt.Block() != nil:
+		return b.block(stmt.Block())
+
+	case stmt.IfStmt() != nil:
+		return b.ifStmt(stmt.IfStmt())
+
+	case stmt.SwitchStmt() != nil:
+		return b.switchStmt(stmt.SwitchStmt())
+
+	case stmt.ForStmt() != nil:
+		return b.forStmt(stmt.ForStmt())
+
+	case stmt.DeferStmt() != nil:
+		deferStmt := stmt.DeferStmt().(*DeferStmtContext)
+		call, ok := b.callExpr(deferStmt.Expression(), "defer")
+		if !ok {
+			return &ast.BadStmt{From: b.pos(deferStmt)}
+		}
+		return &ast.DeferStmt{Defer: b.tokenPos(deferStmt.DEFER()), Call: call}
+
+	case stmt.SelectStmt() != nil:
+		b.errorf(stmt, "select statements are not supported")
+		return &ast.BadStmt{From: b.pos(stmt)}
+	}
+
+	b.errorf(stmt, "unsupported statement: %s", stmt.GetText())
+	return &ast.BadStmt{From: b.pos(stmt)}
+}
+
+func (b *builder) branchStmt(keyword antlr.TerminalNode, tok token.Token, label antlr.TerminalNode) *ast.BranchStmt {
+	branch := &ast.BranchStmt{TokPos: b.tokenPos(keyword), Tok: tok}
+	if label != nil {
+		branch.Label = b.ident(label)
+	}
+
+	return branch
+}
+
+// callExpr builds the operand of a go or defer statement, which must be a
+// function call.
+func (b *builder) callExpr(ctx IExpressionContext, keyword string) (*ast.CallExpr, bool) {
+	call, ok := ast.Unparen(b.expression(ctx)).(*ast.CallExpr)
+	if !ok {
+		b.errorf(ctx.(*ExpressionContext), "expression in %s must be function call", keyword)
+	}
+
+	return call, ok
+}
+
+func (b *builder) simpleStmt(ctx ISimpleStmtContext) ast.Stmt {
+	stmt := ctx.(*SimpleStmtContext)
+
+	if c := stmt.ExpressionStmt(); c != nil {
+		return &ast.ExprStmt{X: b.expression(c.(*ExpressionStmtContext).Expression())}
+	}
+
+	if c := stmt.ShortVarDecl(); c != nil {
+		decl := c.(*ShortVarDeclContext)
+		var lhs []ast.Expr
+		for _, name := range b.identifierList(decl.IdentifierList()) {
+			lhs = append(lhs, name)
+		}
+		return &ast.AssignStmt{
+			Lhs:    lhs,
+			TokPos: b.tokenPos(decl.DECLARE_ASSIGN()),
+			Tok:    token.DEFINE,
+			Rhs:    b.expressionList(decl.ExpressionList()),
+		}
+	}
+
+	if c := stmt.Assignment(); c != nil {
+		assignment := c.(*AssignmentContext)
+		assignOp := assignment.Assign_op().(*Assign_opContext)
+
+		tok := token.ASSIGN
+		if assignOp.GetChildCount() > 1 {
+			op := assignOp.GetChild(0).(antlr.TerminalNode).GetSymbol().GetTokenType()
+			tok = assignOps[binaryOps[op]]
+		}
+
+		return &ast.AssignStmt{
+			Lhs:    b.expressionList(assignment.ExpressionList(0)),
+			TokPos: b.pos(assignOp),
+			Tok:    tok,
+			Rhs:    b.expressionList(assignment.ExpressionList(1)),
+		}
+	}
+
+	if c := stmt.IncDecStmt(); c != nil {
+		incDec := c.(*IncDecStmtContext)
+		incDecStmt := &ast.IncDecStmt{X: b.expression(incDec.Expression())}
+		if incDec.PLUS_PLUS() != nil {
+			incDecStmt.TokPos, incDecStmt.Tok = b.tokenPos(incDec.PLUS_PLUS()), token.INC
+		} else {
+			incDecStmt.TokPos, incDecStmt.Tok = b.tokenPos(incDec.MINUS_MINUS()), token.DEC
+		}
+		return incDecStmt
+	}
+
+	if c := stmt.SendStmt(); c != nil {
+		send := c.(*SendStmtContext)
+		return &ast.SendStmt{
+			Chan:  b.expression(send.Expression(0)),
+			Arrow: b.tokenPos(send.RECEIVE()),
+			Value: b.expression(send.Expression(1)),
+		}
+	}
+
+	b.errorf(stmt, "unsupported statement: %s", stmt.GetText())
+	return &ast.BadStmt{From: b.pos(stmt)}
+}
+
+func (b *builder) ifStmt(ctx IIfStmtContext) *ast.IfStmt {
+	ifCtx := ctx.(*IfStmtContext)
+	ifStmt := &ast.IfStmt{
+		If:   b.tokenPos(ifCtx.IF()),
+		Cond: b.expression(ifCtx.Expression()),
+		Body: b.block(ifCtx.Block(0)),
+	}
+
+	if ifCtx.SimpleStmt() != nil {
+		ifStmt.Init = b.simpleStmt(ifCtx.SimpleStmt())
+	}
+
+	if ifCtx.IfStmt() != nil {
+		ifStmt.Else = b.ifStmt(ifCtx.IfStmt())
+	} else if ifCtx.Block(1) != nil {
+		ifStmt.Else = b.block(ifCtx.Block(1))
+	}
+
+	return ifStmt
+}
+
+func (b *builder) switchStmt(ctx ISwitchStmtContext) ast.Stmt {
+	switchCtx := ctx.(*SwitchStmtContext)
+	if switchCtx.TypeSwitchStmt() != nil {
+		b.errorf(switchCtx, "type switches are not supported")
+		return &ast.BadStmt{From: b.pos(switchCtx)}
+	}
+
+	exprSwitch := switchCtx.ExprSwitchStmt().(*ExprSwitchStmtContext)
+	switchStmt := &ast.SwitchStmt{
+		Switch: b.tokenPos(exprSwitch.SWITCH()),
+		Body: &ast.BlockStmt{
+			Lbrace: b.tokenPos(exprSwitch.L_CURLY()),
+			Rbrace: b.tokenPos(exprSwitch.R_CURLY()),
+		},
+	}
+
+	if exprSwitch.SimpleStmt() != nil {
+		switchStmt.Init = b.simpleStmt(exprSwitch.SimpleStmt())
+	}
+	if exprSwitch.Expression() != nil {
+		switchStmt.Tag = b.expression(exprSwitch.Expression())
+	}
+
+	for _, clauseCtx := range exprSwitch.AllExprCaseClause() {
+		clause := clauseCtx.(*ExprCaseClauseContext)
+		switchCase := clause.ExprSwitchCase().(*ExprSwitchCaseContext)
+
+		caseClause := &ast.CaseClause{
+			Case:  b.pos(switchCase),
+			Colon: b.tokenPos(clause.COLON()),
+			Body:  b.statementList(clause.StatementList()),
+		}
+		if switchCase.CASE() != nil {
+			caseClause.List = b.expressionList(switchCase.ExpressionList())
+		}
+
+		switchStmt.Body.List = append(switchStmt.Body.List, caseClause)
+	}
+
+	return switchStmt
+}
+
+func (b *builder) forStmt(ctx IForStmtContext) ast.Stmt {
+	forCtx := ctx.(*ForStmtContext)
+	forPos := b.tokenPos(forCtx.FOR())
+	body := b.block(forCtx.Block())
+
+	if rangeCtx := forCtx.RangeClause(); rangeCtx != nil {
+		return b.rangeStmt(forPos, rangeCtx.(*RangeClauseContext), body)
+	}
+
+	forStmt := &ast.ForStmt{For: forPos, Body: body}
+
+	if forCtx.Expression() != nil {
+		forStmt.Cond = b.expression(forCtx.Expression())
+	}
+
+	if clauseCtx := forCtx.ForClause(); clauseCtx != nil {
+		clause := clauseCtx.(*ForClauseContext)
+		if clause.GetInitStmt() != nil {
+			forStmt.Init = b.simpleStmt(clause.GetInitStmt())
+		}
+		if clause.Expression() != nil {
+			forStmt.Cond = b.expression(clause.Expression())
+		}
+		if clause.GetPostStmt() != nil {
+			forStmt.Post = b.simpleStmt(clause.GetPostStmt())
+		}
+	}
+
+	return forStmt
+}
+
+func (b *builder) rangeStmt(forPos diag.Position, ctx *RangeClauseContext, body *ast.BlockStmt) ast.Stmt {
+	rangeStmt := &ast.RangeStmt{
+		For:  forPos,
+		Tok:  token.ILLEGAL,
+		X:    b.expression(ctx.Expression()),
+		Body: body,
+	}
+
+	var lhs []ast.Expr
+	switch {
+	case ctx.IdentifierList() != nil:
+		for _, name := range b.identifierList(ctx.IdentifierList()) {
+			lhs = append(lhs, name)
+		}
+		rangeStmt.TokPos, rangeStmt.Tok = b.tokenPos(ctx.DECLARE_ASSIGN()), token.DEFINE
+	case ctx.ExpressionList() != nil:
+		lhs = b.expressionList(ctx.ExpressionList())
+		rangeStmt.TokPos, rangeStmt.Tok = b.tokenPos(ctx.ASSIGN()), token.ASSIGN
+	}
+
+	if len(lhs) > 2 {
+		b.diags.Errorf(lhs[2].Pos(), "range clause permits at most two iteration variables")
+		return &ast.BadStmt{From: forPos}
+	}
+	if len(lhs) > 0 {
+		rangeStmt.Key = lhs[0]
+	}
+	if len(lhs) > 1 {
+		rangeStmt.Value = lhs[1]
+	}
+
+	return rangeStmt
+}
+
+// ----------------------------------------------------------------------------
+// Expressions
+
+func (b *builder) expressionList(ctx IExpressionListContext) []ast.Expr {
+	var list []ast.Expr
+	for _, exprCtx := range ctx.(*ExpressionListContext).AllExpression() {
+		list = append(list, b.expression(exprCtx))
+	}
+
+	return list
+}
+
+func (b *builder) expression(ctx IExpressionContext) ast.Expr {
+	expr := ctx.(*ExpressionContext)
+
+	if primary := expr.PrimaryExpr(); primary != nil {
+		return b.primaryExpr(primary)
+	}
+
+	if op := expr.GetUnary_op(); op != nil {
+		opPos := diag.TokenPosition(b.filename, op)
+		x := b.expression(expr.Expression(0))
+		if op.GetTokenType() == GoParserSTAR {
+			return &ast.StarExpr{Star: opPos, X: x}
+		}
+		return &ast.UnaryExpr{OpPos: opPos, Op: unaryOps[op.GetTokenType()], X: x}
+	}
+
+	var op antlr.Token
+	switch {
+	case expr.GetMul_op() != nil:
+		op = expr.GetMul_op()
+	case expr.GetAdd_op() != nil:
+		op = expr.GetAdd_op()
+	case expr.GetRel_op() != nil:
+		op = expr.GetRel_op()
+	case expr.LOGICAL_AND() != nil:
+		op = expr.LOGICAL_AND().GetSymbol()
+	case expr.LOGICAL_OR() != nil:
+		op = expr.LOGICAL_OR().GetSymbol()
+	default:
+		b.errorf(expr, "unsupported expression: %s", expr.GetText())
+		return &ast.BadExpr{From: b.pos(expr)}
+	}
+
+	return &ast.BinaryExpr{
+		X:     b.expression(expr.Expression(0)),
+		OpPos: diag.TokenPosition(b.filename, op),
+		Op:    binaryOps[op.GetTokenType()],
+		Y:     b.expression(expr.Expression(1)),
+	}
+}
+
+func (b *builder) primaryExpr(ctx IPrimaryExprContext) ast.Expr {
+	primary := ctx.(*PrimaryExprContext)
+
+	if operand := primary.Operand(); operand != nil {
+		return b.operand(operand.(*OperandContext))
+	}
+
+	if conversionCtx := primary.Conversion(); conversionCtx != nil {
+		conversion := conversionCtx.(*ConversionContext)
+		return &ast.CallExpr{
+			Fun:    b.nonNamedType(conversion.NonNamedType()),
+			Lparen: b.tokenPos(conversion.L_PAREN()),
+			Args:   []ast.Expr{b.expression(conversion.Expression())},
+		}
+	}
+
+	if methodCtx := primary.MethodExpr(); methodCtx != nil {
+		method := methodCtx.(*MethodExprContext)
+		return &ast.SelectorExpr{
+			X:   b.nonNamedType(method.NonNamedType()),
+			Sel: b.ident(method.IDENTIFIER()),
+		}
+	}
+
+	x := b.primaryExpr(primary.PrimaryExpr())
+
+	switch {
+	case primary.IDENTIFIER() != nil:
+		return &ast.SelectorExpr{X: x, Sel: b.ident(primary.IDENTIFIER())}
+
+	case primary.Index() != nil:
+		index := primary.Index().(*IndexContext)
+		return &ast.IndexExpr{
+			X:      x,
+			Lbrack: b.tokenPos(index.L_BRACKET()),
+			Index:  b.expression(index.Expression()),
+		}
+
+	case primary.Slice_() != nil:
+		return b.sliceExpr(x, primary.Slice_().(*Slice_Context))
+
+	case primary.TypeAssertion() != nil:
+		assertion := primary.TypeAssertion().(*TypeAssertionContext)
+		return &ast.TypeAssertExpr{X: x, Type: b.type_(assertion.Type_())}
+
+	case primary.Arguments() != nil:
+		return b.callArguments(x, primary.Arguments().(*ArgumentsContext))
+	}
+
+	b.errorf(primary, "unsupported expression: %s", primary.GetText())
+	return &ast.BadExpr{From: b.pos(primary)}
+}
+
+// sliceExpr assigns the optional indices of x[low:high:max] by the number
+// of colons in front of them.
+func (b *builder) sliceExpr(x ast.Expr, ctx *Slice_Context) *ast.SliceExpr {
+	slice := &ast.SliceExpr{
+		X:      x,
+		Lbrack: b.tokenPos(ctx.L_BRACKET()),
+		Slice3: len(ctx.AllCOLON()) == 2,
+	}
+
+	colons := 0
+	for _, child := range ctx.GetChildren() {
+		switch c := child.(type) {
+		case antlr.TerminalNode:
+			if c.GetSymbol().GetTokenType() == GoParserCOLON {
+				colons++
+			}
+		case *ExpressionContext:
+			index := b.expression(c)
+			switch colons {
+			case 0:
+				slice.Low = index
+			case 1:
+				slice.High = index
+			default:
+				slice.Max = index
+			}
+		}
+	}
+
+	return slice
+}
+
+func (b *builder) callArguments(fun ast.Expr, ctx *ArgumentsContext) *ast.CallExpr {
+	call := &ast.CallExpr{
+		Fun:      fun,
+		Lparen:   b.tokenPos(ctx.L_PAREN()),
+		Ellipsis: ctx.ELLIPSIS() != nil,
+	}
+
+	// The first argument of make and new may be a type literal.
+	if typ := ctx.NonNamedType(); typ != nil {
+		call.Args = append(call.Args, b.nonNamedType(typ))
+	}
+	if list := ctx.ExpressionList(); list != nil {
+		call.Args = append(call.Args, b.expressionList(list)...)
+	}
+
+	return call
+}
+
+func (b *builder) operand(ctx *OperandContext) ast.Expr {
+	if literal := ctx.Literal(); literal != nil {
+		return b.literal(literal.(*LiteralContext))
+	}
+
+	if name := ctx.OperandName(); name != nil {
+		return b.ident(name.(*OperandNameContext).IDENTIFIER())
+	}
+
+	return &ast.ParenExpr{
+		Lparen: b.tokenPos(ctx.L_PAREN()),
+		X:      b.expression(ctx.Expression()),
+	}
+}
+
+func (b *builder) literal(ctx *LiteralContext) ast.Expr {
+	if basic := ctx.BasicLit(); basic != nil {
+		return b.basicLit(basic.(*BasicLitContext))
+	}
+
+	if composite := ctx.CompositeLit(); composite != nil {
+		return b.compositeLit(composite.(*CompositeLitContext))
+	}
+
+	function := ctx.FunctionLit().(*FunctionLitContext)
+	return &ast.FuncLit{
+		Type: b.signature(b.tokenPos(function.FUNC()), function.Signature()),
+		Body: b.block(function.Block()),
+	}
+}
+
+func (b *builder) basicLit(ctx *BasicLitContext) ast.Expr {
+	if nilLit := ctx.NIL_LIT(); nilLit != nil {
+		return b.ident(nilLit)
+	}
+
+	if str := ctx.String_(); str != nil {
+		return b.string_(str)
+	}
+
+	if float := ctx.FLOAT_LIT(); float != nil {
+		return &ast.BasicLit{ValuePos: b.tokenPos(float), Kind: token.FLOAT, Value: float.GetText()}
+	}
+
+	integer := ctx.Integer().(*IntegerContext)
+	kind := token.INT
+	switch {
+	case integer.IMAGINARY_LIT() != nil:
+		kind = token.IMAG
+	case integer.RUNE_LIT() != nil:
+		kind = token.CHAR
+	}
+
+	return &ast.BasicLit{ValuePos: b.pos(integer), Kind: kind, Value: integer.GetText()}
+}
+
+func (b *builder) string_(ctx IString_Context) *ast.BasicLit {
+	str := ctx.(*String_Context)
+	return &ast.BasicLit{ValuePos: b.pos(str), Kind: token.STRING, Value: str.GetText()}
+}
+
+func (b *builder) compositeLit(ctx *CompositeLitContext) *ast.CompositeLit {
+	literalType := ctx.LiteralType().(*LiteralTypeContext)
+
+	var typ ast.Expr
+	switch {
+	case literalType.StructType() != nil:
+		typ = b.structType(literalType.StructType())
+	case literalType.ArrayType() != nil:
+		typ = b.arrayType(literalType.ArrayType())
+	case literalType.ELLIPSIS() != nil:
+		typ = &ast.ArrayType{
+			Lbrack: b.tokenPos(literalType.L_BRACKET()),
+			Len:    &ast.Ellipsis{Ellipsis: b.tokenPos(literalType.ELLIPSIS())},
+			Elt:    b.elementType(literalType.ElementType()),
+		}
+	case literalType.SliceType() != nil:
+		slice := literalType.SliceType().(*SliceTypeContext)
+		typ = &ast.ArrayType{Lbrack: b.tokenPos(slice.L_BRACKET()), Elt: b.elementType(slice.ElementType())}
+	case literalType.MapType() != nil:
+		mapType := literalType.MapType().(*MapTypeContext)
+		typ = &ast.MapType{
+			Map:   b.tokenPos(mapType.MAP()),
+			Key:   b.type_(mapType.Type_()),
+			Value: b.elementType(mapType.ElementType()),
+		}
+	default:
+		typ = b.typeName(literalType.TypeName())
+	}
+
+	return b.literalValue(typ, ctx.LiteralValue())
+}
+
+// literalValue builds the {...} part of a composite literal, typ is nil for
+// the literals nested without a type.
+func (b *builder) literalValue(typ ast.Expr, ctx ILiteralValueContext) *ast.CompositeLit {
+	value := ctx.(*LiteralValueContext)
+	lit := &ast.CompositeLit{Type: typ, Lbrace: b.tokenPos(value.L_CURLY()), Rbrace: b.tokenPos(value.R_CURLY())}
+
+	if value.ElementList() == nil {
+		return lit
+	}
+
+	for _, elementCtx := range value.ElementList().(*ElementListContext).AllKeyedElement() {
+		keyed := elementCtx.(*KeyedElementContext)
+
+		element := keyed.Element().(*ElementContext)
+		var elt ast.Expr
+		if element.Expression() != nil {
+			elt = b.expression(element.Expression())
+		} else {
+			elt = b.literalValue(nil, element.LiteralValue())
+		}
+
+		if keyCtx := keyed.Key(); keyCtx != nil {
+			key := keyCtx.(*KeyContext)
+			var keyExpr ast.Expr
+			if key.Expression() != nil {
+				keyExpr = b.expression(key.Expression())
+			} else {
+				keyExpr = b.literalValue(nil, key.LiteralValue())
+			}
+			elt = &ast.KeyValueExpr{Key: keyExpr, Colon: b.tokenPos(keyed.COLON()), Value: elt}
+		}
+
+		lit.Elts = append(lit.Elts, elt)
+	}
+
+	return lit
+}
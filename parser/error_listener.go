@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+
+	"nano-go/diag"
+)
+
+// maxExpectedTokens limits the expected-token hint of a syntax error, longer
+// lists say nothing useful.
+const maxExpectedTokens = 5
+
+// Display names of the symbolic tokens that have no literal spelling.
+var tokenDisplayNames = map[string]string{
+	"IDENTIFIER":             "name",
+	"EOS":                    "newline",
+	"NIL_LIT":                "nil",
+	"DECIMAL_LIT":            "literal",
+	"BINARY_LIT":             "literal",
+	"OCTAL_LIT":              "literal",
+	"HEX_LIT":                "literal",
+	"FLOAT_LIT":              "literal",
+	"IMAGINARY_LIT":          "literal",
+	"RUNE_LIT":               "literal",
+	"RAW_STRING_LIT":         "literal",
+	"INTERPRETED_STRING_LIT": "literal",
+}
+
+// ErrorListener records the syntax errors of the lexer and the parser in a
+// diagnostics list, in place of the default console listener.
+type ErrorListener struct {
+	*antlr.DefaultErrorListener
+
+	Filename string
+	Diags    *diag.List
+}
+
+func NewErrorListener(filename string, diags *diag.List) *ErrorListener {
+	return &ErrorListener{
+		DefaultErrorListener: antlr.NewDefaultErrorListener(),
+		Filename:             filename,
+		Diags:                diags,
+	}
+}
+
+func (l *ErrorListener) SyntaxError(recognizer antlr.Recognizer, offendingSymbol interface{}, line, column int, msg string, e antlr.RecognitionException) {
+	pos := diag.Position{Filename: l.Filename, Line: line, Column: column + 1}
+
+	p, isParser := recognizer.(antlr.Parser)
+	token, isToken := offendingSymbol.(antlr.Token)
+	if !isParser || !isToken {
+		// Lexer errors carry no token, ANTLR's message names the bad input.
+		l.Diags.Errorf(pos, "syntax error: %s", msg)
+		return
+	}
+
+	message := "syntax error: unexpected " + displayToken(token)
+
+	if _, noViableAlt := e.(*antlr.NoViableAltException); !noViableAlt {
+		expected := expectedTokens(p)
+		if len(expected) > 0 && len(expected) <= maxExpectedTokens {
+			message += ", expecting " + joinAlternatives(expected)
+		}
+	}
+
+	l.Diags.Errorf(pos, "%s", message)
+}
+
+func displayToken(token antlr.Token) string {
+	switch token.GetTokenType() {
+	case antlr.TokenEOF:
+		return "EOF"
+	case GoParserEOS:
+		return "newline"
+	case GoParserIDENTIFIER:
+		return "name " + token.GetText()
+	case GoParserDECIMAL_LIT, GoParserBINARY_LIT, GoParserOCTAL_LIT, GoParserHEX_LIT,
+		GoParserFLOAT_LIT, GoParserIMAGINARY_LIT, GoParserRUNE_LIT,
+		GoParserRAW_STRING_LIT, GoParserINTERPRETED_STRING_LIT:
+		return "literal " + token.GetText()
+	}
+
+	return token.GetText()
+}
+
+// expectedTokens lists the display names of the tokens the parser would have
+// accepted at its current state, without duplicates.
+func expectedTokens(p antlr.Parser) []string {
+	set := p.GetExpectedTokens()
+	if set == nil {
+		return nil
+	}
+
+	verbose := set.StringVerbose(p.GetLiteralNames(), p.GetSymbolicNames(), false)
+	verbose = strings.TrimSuffix(strings.TrimPrefix(verbose, "{"), "}")
+	if verbose == "" {
+		return nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(verbose, ", ") {
+		if display, ok := tokenDisplayNames[name]; ok {
+			name = display
+		} else if name == "<EOF>" {
+			name = "EOF"
+		} else {
+			name = strings.Trim(name, "'")
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func joinAlternatives(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+
+	return fmt.Sprintf("%s or %s", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+}
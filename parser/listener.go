@@ -2,11 +2,7 @@
 
 package parser // GoParser
 
-import (
-	"fmt"
-	"github.com/antlr/antlr4/runtime/Go/antlr"
-	"nano-go/ast"
-)
+import "github.com/antlr/antlr4/runtime/Go/antlr"
 
 // GoListener is a complete listener for a parse tree produced by GoParser.
 type GoListener struct{}
@@ -98,29 +94,10 @@ func (s *GoListener) EnterTypeSpec(ctx *TypeSpecContext) {}
 func (s *GoListener) ExitTypeSpec(ctx *TypeSpecContext) {}
 
 // EnterFunctionDecl is called when production functionDecl is entered.
-func (s *GoListener) EnterFunctionDecl(ctx *FunctionDeclContext) {
-
-}
+func (s *GoListener) EnterFunctionDecl(ctx *FunctionDeclContext) {}
 
 // ExitFunctionDecl is called when production functionDecl is exited.
-func (s *GoListener) ExitFunctionDecl(ctx *FunctionDeclContext) {
-	fmt.Println(ctx.Signature().GetText())
-	fmt.Println(ctx.GetText())
-	block := ctx.Block()
-
-	fmt.Println(block)
-	fNode := ast.FuncLit{
-		Type: &ast.FuncType{
-
-		},
-		Body: &ast.BlockStmt{
-
-		},
-	}
-	fmt.Println(fNode)
-	fmt.Println(ctx.IDENTIFIER())
-	fmt.Println(ctx.FUNC())
-}
+func (s *GoListener) ExitFunctionDecl(ctx *FunctionDeclContext) {}
 
 // EnterMethodDecl is called when production methodDecl is entered.
 func (s *GoListener) EnterMethodDecl(ctx *MethodDeclContext) {}
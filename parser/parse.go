@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+
+	"nano-go/ast"
+	"nano-go/diag"
+)
+
+// ParseFile parses the source of a file and builds its syntax tree. Syntax
+// errors are reported to diags, no tree is built when there are any.
+func ParseFile(filename, src string, diags *diag.List) *ast.File {
+	listener := NewErrorListener(filename, diags)
+
+	is := antlr.NewInputStream(src)
+
+	lexer := NewGoLexer(is)
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(listener)
+	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+	p := NewGoParser(stream)
+	p.RemoveErrorListeners()
+	p.AddErrorListener(listener)
+
+	sourceFile := p.SourceFile().(*SourceFileContext)
+	if diags.HasErrors() {
+		// The tree of a file with syntax errors is incomplete.
+		return nil
+	}
+
+	return BuildFile(filename, sourceFile, diags)
+}
@@ -0,0 +1,28 @@
+package visitor
+
+import (
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/visitor/type"
+)
+
+// newArray allocates n zeroed elements of type elem on the heap and returns
+// the pointer to the first one.
+func (v *Visitor) newArray(elem _type.Type, n value.Value) value.Value {
+	size := constant.NewInt(types.I64, elem.Size())
+	mem := v.curBlock.NewCall(v.calloc(), n, size)
+	return v.curBlock.NewBitCast(mem, types.NewPointer(memoryType(elem.LLVM())))
+}
+
+func (v *Visitor) calloc() *ir.Func {
+	if v.callocFn == nil {
+		v.callocFn = v.Module.NewFunc("calloc", types.NewPointer(types.I8),
+			ir.NewParam("count", types.I64),
+			ir.NewParam("size", types.I64),
+		)
+	}
+	return v.callocFn
+}
@@ -0,0 +1,186 @@
+package visitor
+
+import (
+	goconstant "go/constant"
+	"go/token"
+
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/ast"
+	"nano-go/check"
+	"nano-go/visitor/pointer"
+	"nano-go/visitor/type"
+)
+
+// visitCompositeLit lowers an array, slice, map or struct literal.
+func (v *Visitor) visitCompositeLit(lit *ast.CompositeLit) value.Value {
+	switch t := v.typeOf(lit).Underlying().(type) {
+	case *check.Map:
+		return v.visitMapLit(lit, t)
+	case *check.Struct:
+		return v.visitStructLit(lit, t)
+	}
+
+	indices, length := v.elementIndices(lit)
+
+	if t, ok := v.goType(v.typeOf(lit)).(*_type.SliceType); ok {
+		return v.visitSliceLit(lit, t, indices, length)
+	}
+
+	// The elements of an array start as zeros, the ones of the literal are
+	// inserted at their index.
+	var arr value.Value = constant.NewZeroInitializer(v.llvmType(v.typeOf(lit)))
+	for i, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			elt = kv.Value
+		}
+		arr = v.curBlock.NewInsertValue(arr, v.toMemory(v.visitExpression(elt)), uint64(indices[i]))
+	}
+
+	return arr
+}
+
+// elementIndices returns the indices of the elements of an array or slice
+// literal, and the length they need.
+func (v *Visitor) elementIndices(lit *ast.CompositeLit) ([]int64, int64) {
+	indices := make([]int64, len(lit.Elts))
+
+	var index, length int64
+	for i, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			// The checker made sure the keys are constant.
+			index, _ = goconstant.Int64Val(v.Info.Types[kv.Key].Value)
+		}
+		indices[i] = index
+		index++
+		if index > length {
+			length = index
+		}
+	}
+
+	return indices, length
+}
+
+// visitIndexExpr lowers the indexing of an array, a slice, a string or a
+// map.
+func (v *Visitor) visitIndexExpr(expr *ast.IndexExpr) value.Value {
+	if t, ok := v.typeOf(expr.X).Underlying().(*check.Map); ok {
+		return v.visitMapIndex(expr, t)
+	}
+	return v.load(v.llvmType(v.typeOf(expr)), v.indexAddr(expr))
+}
+
+// indexAddr returns the address of the element expr denotes. An array that
+// is not a variable is stored in a temporary one first.
+func (v *Visitor) indexAddr(expr *ast.IndexExpr) value.Value {
+	switch t := v.typeOf(expr.X).Underlying().(type) {
+	case *check.Basic:
+		str := v.visitExpression(expr.X)
+		i := v.index(expr.Index, v.curBlock.NewExtractValue(str, 0))
+		return v.curBlock.NewGetElementPtr(types.I8, v.curBlock.NewExtractValue(str, 1), i)
+
+	case *check.Array:
+		arrayType := v.llvmType(t)
+
+		var arr value.Value
+		if v.Info.Types[expr.X].Addressable() {
+			arr = v.address(expr.X)
+		} else {
+			arr = v.alloca(arrayType, "")
+			v.store(v.visitExpression(expr.X), arr)
+		}
+
+		i := v.index(expr.Index, constant.NewInt(types.I64, t.Len()))
+		return v.curBlock.NewGetElementPtr(arrayType, arr, constant.NewInt(types.I64, 0), i)
+
+	case *check.Slice:
+		slice := v.visitExpression(expr.X)
+		data := v.curBlock.NewExtractValue(slice, 0)
+		i := v.index(expr.Index, v.curBlock.NewExtractValue(slice, 1))
+		return v.curBlock.NewGetElementPtr(pointer.ElemType(data), data, i)
+	}
+
+	v.fatalf(expr, "indexing of %s is not implemented yet", v.typeOf(expr.X))
+	return nil
+}
+
+// index lowers the index expr of a value of length n to an i64 and checks
+// it is in range.
+func (v *Visitor) index(expr ast.Expr, n value.Value) value.Value {
+	i := v.toInt64(v.visitExpression(expr), v.typeOf(expr))
+	v.boundsCheck(expr, boundsIndex, i, n, !v.isUnsigned(v.typeOf(expr)))
+	return i
+}
+
+// toInt64 extends the integer val of type t to an i64, an index or a size.
+func (v *Visitor) toInt64(val value.Value, t check.Type) value.Value {
+	c, isConst := val.(*constant.Int)
+	switch {
+	case val.Type().(*types.IntType).BitSize == 64:
+		return val
+	case isConst:
+		return constant.NewInt(types.I64, c.X.Int64())
+	case v.isUnsigned(t):
+		return v.curBlock.NewZExt(val, types.I64)
+	}
+	return v.curBlock.NewSExt(val, types.I64)
+}
+
+// address returns the address of the variable expr denotes: a name, an
+// element of an addressable array or of a slice, a field of a variable, or
+// the map element it is assigned to.
+func (v *Visitor) address(expr ast.Expr) value.Value {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return v.lookupVar(e).Value
+	case *ast.ParenExpr:
+		return v.address(e.X)
+	case *ast.IndexExpr:
+		if t, ok := v.typeOf(e.X).Underlying().(*check.Map); ok {
+			return v.mapElemAddr(e, t)
+		}
+		return v.indexAddr(e)
+	case *ast.SelectorExpr:
+		return v.fieldAddr(e)
+	}
+
+	v.fatalf(expr, "assignment to %s is not implemented yet", check.ExprString(expr))
+	return nil
+}
+
+// visitLenCall lowers a call of len or cap that is not constant. The
+// length of an array is constant, its argument is only evaluated for the
+// calls it has.
+func (v *Visitor) visitLenCall(call *ast.CallExpr, builtin string) value.Value {
+	arg := v.visitExpression(call.Args[0])
+
+	switch t := v.typeOf(call.Args[0]).Underlying().(type) {
+	case *check.Basic:
+		return v.curBlock.NewExtractValue(arg, 0)
+	case *check.Array:
+		return constant.NewInt(types.I64, t.Len())
+	case *check.Slice:
+		if builtin == "cap" {
+			return v.curBlock.NewExtractValue(arg, 2)
+		}
+		return v.curBlock.NewExtractValue(arg, 1)
+	case *check.Map:
+		return v.curBlock.NewCall(v.mapLenFunc(), arg)
+	}
+
+	v.fatalf(call, "%s of %s is not implemented yet", builtin, v.typeOf(call.Args[0]))
+	return nil
+}
+
+// visitCompositeComparison lowers == and != of strings, arrays and structs
+// with the equality function of map keys of their type, which takes the
+// operands by address.
+func (v *Visitor) visitCompositeComparison(expr *ast.BinaryExpr, x, y value.Value) value.Value {
+	eq := v.curBlock.NewCall(v.equalFunc(v.typeOf(expr.X)), v.keyAddr(x), v.keyAddr(y))
+	if expr.Op == token.NEQ {
+		return v.curBlock.NewXor(eq, constant.True)
+	}
+	return eq
+}
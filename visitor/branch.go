@@ -0,0 +1,80 @@
+package visitor
+
+import (
+	"go/token"
+
+	"github.com/llir/llvm/ir"
+
+	"nano-go/ast"
+	"nano-go/visitor/name"
+)
+
+// branchTarget is a loop or a switch break and continue statements may
+// branch out of.
+type branchTarget struct {
+	label         string
+	breakBlock    *ir.Block
+	continueBlock *ir.Block // nil for a switch
+}
+
+// pushBranchTarget enters a loop or a switch, it takes the label of the
+// statement being visited.
+func (v *Visitor) pushBranchTarget(breakBlock, continueBlock *ir.Block) {
+	v.branches = append(v.branches, branchTarget{v.label, breakBlock, continueBlock})
+	v.label = ""
+}
+
+func (v *Visitor) popBranchTarget() {
+	v.branches = v.branches[:len(v.branches)-1]
+}
+
+// labelBlock returns the block starting the statement labeled label. Goto
+// statements may refer to it before the label is visited.
+func (v *Visitor) labelBlock(label string) *ir.Block {
+	block, ok := v.labels[label]
+	if !ok {
+		block = v.curBlock.Parent.NewBlock(name.BlockName() + "-label-" + label)
+		v.labels[label] = block
+	}
+	return block
+}
+
+func (v *Visitor) visitLabeledStmt(stmt *ast.LabeledStmt) {
+	block := v.labelBlock(stmt.Label.Name)
+	if v.curBlock.Term == nil {
+		v.curBlock.NewBr(block)
+	}
+	v.curBlock = block
+
+	switch stmt.Stmt.(type) {
+	case *ast.ForStmt, *ast.SwitchStmt, *ast.RangeStmt:
+		v.label = stmt.Label.Name
+	}
+	v.visitStatement(stmt.Stmt)
+}
+
+func (v *Visitor) visitBranchStmt(stmt *ast.BranchStmt) {
+	if stmt.Tok == token.GOTO {
+		v.curBlock.NewBr(v.labelBlock(stmt.Label.Name))
+		return
+	}
+
+	// The checker made sure that there is a target.
+	for i := len(v.branches) - 1; i >= 0; i-- {
+		target := v.branches[i]
+		if stmt.Label != nil && target.label != stmt.Label.Name {
+			continue
+		}
+
+		switch {
+		case stmt.Tok == token.BREAK:
+			v.curBlock.NewBr(target.breakBlock)
+			return
+		case target.continueBlock != nil:
+			v.curBlock.NewBr(target.continueBlock)
+			return
+		}
+	}
+
+	v.fatalf(stmt, "%s statement has no target", stmt.Tok)
+}
@@ -0,0 +1,61 @@
+package visitor
+
+import (
+	"fmt"
+	goconstant "go/constant"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/check"
+	"nano-go/visitor/pointer"
+	"nano-go/visitor/strings"
+	"nano-go/visitor/type"
+)
+
+// constValue lowers the value of a constant expression of type typ.
+func (v *Visitor) constValue(val goconstant.Value, typ check.Type) value.Value {
+	switch val.Kind() {
+	case goconstant.Bool:
+		return constant.NewBool(goconstant.BoolVal(val))
+
+	case goconstant.Int, goconstant.Float:
+		if floatType, ok := v.llvmType(typ).(*types.FloatType); ok {
+			x, _ := goconstant.Float64Val(goconstant.ToFloat(val))
+			return constant.NewFloat(floatType, x)
+		}
+
+		intType := v.llvmType(typ).(*types.IntType)
+		if x, ok := goconstant.Int64Val(val); ok {
+			return constant.NewInt(intType, x)
+		}
+		// Unsigned values above MaxInt64 keep their bits.
+		x, _ := goconstant.Uint64Val(val)
+		return constant.NewInt(intType, int64(x))
+
+	case goconstant.String:
+		return v.stringConstant(goconstant.StringVal(val))
+	}
+
+	panic(fmt.Sprintf("constant %s of type %s cannot be lowered", val, typ))
+}
+
+func (v *Visitor) stringConstant(valueStr string) value.Value {
+	var constString *ir.Global
+	constString = v.Module.NewGlobalDef(strings.NextStringName(), strings.Constant(valueStr))
+	constString.Immutable = true
+
+	alloc := v.alloca(_type.String.LLVM(), "")
+
+	// Save length of the string
+	lenItem := v.curBlock.NewGetElementPtr(pointer.ElemType(alloc), alloc, constant.NewInt(types.I32, 0), constant.NewInt(types.I32, 0))
+	v.curBlock.NewStore(constant.NewInt(types.I64, int64(len(valueStr))), lenItem)
+
+	// Save i8* version of string
+	strItem := v.curBlock.NewGetElementPtr(pointer.ElemType(alloc), alloc, constant.NewInt(types.I32, 0), constant.NewInt(types.I32, 1))
+	v.curBlock.NewStore(strings.Toi8Ptr(v.curBlock, constString), strItem)
+
+	return v.curBlock.NewLoad(pointer.ElemType(alloc), alloc)
+}
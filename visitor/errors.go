@@ -0,0 +1,31 @@
+package visitor
+
+import "nano-go/ast"
+
+// bailout is raised by fatalf to abandon the statement being visited after
+// its error has been reported. It is recovered by recoverStatement.
+type bailout struct{}
+
+// errorf reports a recoverable error at the position of node.
+func (v *Visitor) errorf(node ast.Node, format string, args ...interface{}) {
+	v.Diags.Errorf(node.Pos(), format, args...)
+}
+
+// fatalf reports an error at the position of node and abandons the current
+// statement, visiting continues with the next one.
+func (v *Visitor) fatalf(node ast.Node, format string, args ...interface{}) {
+	v.errorf(node, format, args...)
+	panic(bailout{})
+}
+
+// recoverStatement must be deferred by the visitors of statements and
+// declarations. It stops a bailout and any panic caused by code generation
+// on top of an already reported error, so the rest of the file is still
+// checked.
+func (v *Visitor) recoverStatement() {
+	if r := recover(); r != nil {
+		if _, ok := r.(bailout); !ok && !v.Diags.HasErrors() {
+			panic(r)
+		}
+	}
+}
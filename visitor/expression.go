@@ -1,8 +1,7 @@
 package visitor
 
 import (
-	"fmt"
-	"strconv"
+	"go/token"
 
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
@@ -10,185 +9,446 @@ import (
 	"github.com/llir/llvm/ir/types"
 	"github.com/llir/llvm/ir/value"
 
-	"nano-go/parser"
+	"nano-go/ast"
+	"nano-go/check"
+	"nano-go/visitor/name"
 	"nano-go/visitor/pointer"
-	"nano-go/visitor/strings"
 	"nano-go/visitor/type"
 )
 
-func (v *Visitor) visitExpression(ctx *parser.ExpressionContext) value.Value {
-	unary := ctx.GetUnary_op()
-	if unary != nil {
-		if unary.GetTokenType() == parser.GoParserMINUS {
-			exprValue := v.visitExpression(ctx.Expression(0).(*parser.ExpressionContext))
-			valueType := exprValue.Type()
-			switch valueType {
-			case types.I32, types.I64:
-				zero := constant.NewInt(types.I64, 0)
-				return v.curBlock.NewSub(zero, exprValue)
-			}
-		}
+func (v *Visitor) visitExpression(expr ast.Expr) value.Value {
+	// Constant expressions were folded by the checker.
+	if tv := v.Info.Types[expr]; tv.Value != nil {
+		return v.constValue(tv.Value, tv.Type)
 	}
 
-	if addOp := ctx.GetAdd_op(); addOp != nil {
-		leftValue := v.visitExpression(ctx.Expression(0).(*parser.ExpressionContext))
-		rightValue := v.visitExpression(ctx.Expression(1).(*parser.ExpressionContext))
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return v.visitIdent(e)
+	case *ast.ParenExpr:
+		return v.visitExpression(e.X)
+	case *ast.CallExpr:
+		return v.visitCallExpr(e)
+	case *ast.UnaryExpr:
+		return v.visitUnaryExpr(e)
+	case *ast.BinaryExpr:
+		return v.visitBinaryExpr(e)
+	case *ast.CompositeLit:
+		return v.visitCompositeLit(e)
+	case *ast.IndexExpr:
+		return v.visitIndexExpr(e)
+	case *ast.SliceExpr:
+		return v.visitSliceExpr(e)
+	case *ast.SelectorExpr:
+		return v.visitSelectorExpr(e)
+	}
+
+	v.fatalf(expr, "unsupported expression")
+	return nil
+}
 
-		switch addOp.GetTokenType() {
-		case parser.GoParserPLUS:
-			return v.curBlock.NewAdd(leftValue, rightValue)
-		case parser.GoParserMINUS:
-			return v.curBlock.NewSub(leftValue, rightValue)
-		case parser.GoParserOR:
-			return v.curBlock.NewOr(leftValue, rightValue)
-		case parser.GoParserCARET:
-			panic("caret is not implemented yet")
+func (v *Visitor) visitUnaryExpr(expr *ast.UnaryExpr) value.Value {
+	exprValue := v.visitExpression(expr.X)
+
+	switch expr.Op {
+	case token.ADD:
+		return exprValue
+	case token.NOT:
+		return v.curBlock.NewXor(exprValue, constant.True)
+	case token.XOR:
+		allOnes := constant.NewInt(exprValue.Type().(*types.IntType), -1)
+		return v.curBlock.NewXor(exprValue, allOnes)
+	case token.SUB:
+		switch valueType := exprValue.Type().(type) {
+		case *types.FloatType:
+			return v.curBlock.NewFNeg(exprValue)
+		case *types.IntType:
+			zero := constant.NewInt(valueType, 0)
+			return v.curBlock.NewSub(zero, exprValue)
 		}
 	}
 
-	if mulOp := ctx.GetMul_op(); mulOp != nil {
-		leftValue := v.visitExpression(ctx.Expression(0).(*parser.ExpressionContext))
-		rightValue := v.visitExpression(ctx.Expression(1).(*parser.ExpressionContext))
-
-		switch mulOp.GetTokenType() {
-		case parser.GoParserSTAR:
-			return v.curBlock.NewMul(leftValue, rightValue)
-		case parser.GoParserDIV:
-			return v.curBlock.NewSDiv(leftValue, rightValue)
-		case parser.GoParserMOD:
-			panic("mod is not implemented yet")
-		case parser.GoParserLSHIFT:
-			return v.curBlock.NewShl(leftValue, rightValue)
-		case parser.GoParserRSHIFT:
-			return v.curBlock.NewAShr(leftValue, rightValue)
-		case parser.GoParserAMPERSAND:
-			return v.curBlock.NewAnd(leftValue, rightValue)
-		case parser.GoParserBIT_CLEAR:
-			panic("bit clear is not implemented yet")
-		}
+	v.fatalf(expr, "operator %s is not implemented yet", expr.Op)
+	return nil
+}
+
+func (v *Visitor) visitBinaryExpr(expr *ast.BinaryExpr) value.Value {
+	if expr.Op == token.LAND || expr.Op == token.LOR {
+		return v.visitLogicalExpr(expr)
+	}
+
+	return v.binaryOp(expr, v.visitExpression(expr.X), v.visitExpression(expr.Y))
+}
+
+// binaryOp lowers the operation of expr on the values of its operands.
+func (v *Visitor) binaryOp(expr *ast.BinaryExpr, leftValue, rightValue value.Value) value.Value {
+	switch v.typeOf(expr.X).Underlying().(type) {
+	case *check.Array, *check.Struct:
+		return v.visitCompositeComparison(expr, leftValue, rightValue)
+	case *check.Slice:
+		// A slice is only compared to nil, it is nil when it has no
+		// array.
+		leftValue = v.curBlock.NewExtractValue(leftValue, 0)
+		rightValue = v.curBlock.NewExtractValue(rightValue, 0)
+	}
+	if isStringType(v.typeOf(expr.X)) && (expr.Op == token.EQL || expr.Op == token.NEQ) {
+		return v.visitCompositeComparison(expr, leftValue, rightValue)
 	}
 
-	if relOp := ctx.GetRel_op(); relOp != nil {
-		leftValue := v.visitExpression(ctx.Expression(0).(*parser.ExpressionContext))
-		rightValue := v.visitExpression(ctx.Expression(1).(*parser.ExpressionContext))
-
-		switch relOp.GetTokenType() {
-		case parser.GoParserLESS:
-			return v.curBlock.NewICmp(enum.IPredSLT, leftValue, rightValue)
-		case parser.GoParserGREATER:
-			return v.curBlock.NewICmp(enum.IPredSGT, leftValue, rightValue)
-		case parser.GoParserLESS_OR_EQUALS:
-			return v.curBlock.NewICmp(enum.IPredSLE, leftValue, rightValue)
-		case parser.GoParserGREATER_OR_EQUALS:
-			return v.curBlock.NewICmp(enum.IPredSGE, leftValue, rightValue)
-		case parser.GoParserEQUALS:
-			return v.curBlock.NewICmp(enum.IPredEQ, leftValue, rightValue)
-		case parser.GoParserNOT_EQUALS:
-			return v.curBlock.NewICmp(enum.IPredNE, leftValue, rightValue)
+	if v.isFloat(v.typeOf(expr.X)) {
+		return v.visitFloatBinaryExpr(expr, leftValue, rightValue)
+	}
+
+	unsigned := v.isUnsigned(v.typeOf(expr.X))
+	if pred, ok := intPredicates[expr.Op]; ok {
+		if unsigned {
+			pred = unsignedPredicates[expr.Op]
 		}
+		return v.curBlock.NewICmp(pred, leftValue, rightValue)
 	}
 
-	if primaryExpr := ctx.PrimaryExpr(); primaryExpr != nil {
-		return v.visitPrimaryExpr(primaryExpr.(*parser.PrimaryExprContext))
+	switch expr.Op {
+	case token.ADD:
+		return v.curBlock.NewAdd(leftValue, rightValue)
+	case token.SUB:
+		return v.curBlock.NewSub(leftValue, rightValue)
+	case token.OR:
+		return v.curBlock.NewOr(leftValue, rightValue)
+	case token.MUL:
+		return v.curBlock.NewMul(leftValue, rightValue)
+	case token.QUO, token.REM:
+		return v.visitDivision(expr, leftValue, rightValue, unsigned)
+	case token.XOR:
+		return v.curBlock.NewXor(leftValue, rightValue)
+	case token.AND_NOT:
+		allOnes := constant.NewInt(rightValue.Type().(*types.IntType), -1)
+		return v.curBlock.NewAnd(leftValue, v.curBlock.NewXor(rightValue, allOnes))
+	case token.SHL, token.SHR:
+		return v.visitShift(expr, leftValue, rightValue, unsigned)
+	case token.AND:
+		return v.curBlock.NewAnd(leftValue, rightValue)
 	}
 
-	panic("unknown expression")
+	v.fatalf(expr, "operator %s is not implemented yet", expr.Op)
+	return nil
 }
 
-func (v *Visitor) visitPrimaryExpr(ctx *parser.PrimaryExprContext) value.Value {
-	if operand := ctx.Operand(); operand != nil {
-		return v.visitOperand(operand.(*parser.OperandContext))
+// visitLogicalExpr lowers && and ||, the right operand is only evaluated
+// when the left one does not decide the result.
+func (v *Visitor) visitLogicalExpr(expr *ast.BinaryExpr) value.Value {
+	leftValue := v.visitExpression(expr.X)
+	leftBlock := v.curBlock
+
+	rightBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-rhs")
+	afterBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-after")
+
+	if expr.Op == token.LAND {
+		v.curBlock.NewCondBr(leftValue, rightBlock, afterBlock)
+	} else {
+		v.curBlock.NewCondBr(leftValue, afterBlock, rightBlock)
 	}
 
-	if primaryExprI := ctx.PrimaryExpr(); primaryExprI != nil {
-		primaryExpr := primaryExprI.(*parser.PrimaryExprContext)
-		name := primaryExpr.GetText()
-		argumentsI := ctx.Arguments()
-		if argumentsI != nil {
-			arguments := argumentsI.(*parser.ArgumentsContext)
-			var argumentsValues []value.Value
-			for _, exprI := range arguments.ExpressionList().(*parser.ExpressionListContext).AllExpression() {
-				expr := exprI.(*parser.ExpressionContext)
-				exprValue := v.visitExpression(expr)
-				argumentsValues = append(argumentsValues, exprValue)
-			}
-			return v.visitFuncCall(name, argumentsValues)
-		}
+	v.curBlock = rightBlock
+	rightValue := v.visitExpression(expr.Y)
+	v.curBlock.NewBr(afterBlock)
+
+	// The left operand decides the result when it is false for && and
+	// true for ||.
+	decided := constant.NewBool(expr.Op == token.LOR)
+
+	phi := afterBlock.NewPhi(ir.NewIncoming(decided, leftBlock), ir.NewIncoming(rightValue, v.curBlock))
+	v.curBlock = afterBlock
+	return phi
+}
+
+func (v *Visitor) visitFloatBinaryExpr(expr *ast.BinaryExpr, leftValue, rightValue value.Value) value.Value {
+	if pred, ok := floatPredicates[expr.Op]; ok {
+		return v.curBlock.NewFCmp(pred, leftValue, rightValue)
 	}
 
-	panic("unknown primary expression")
+	switch expr.Op {
+	case token.ADD:
+		return v.curBlock.NewFAdd(leftValue, rightValue)
+	case token.SUB:
+		return v.curBlock.NewFSub(leftValue, rightValue)
+	case token.MUL:
+		return v.curBlock.NewFMul(leftValue, rightValue)
+	case token.QUO:
+		return v.curBlock.NewFDiv(leftValue, rightValue)
+	}
+
+	v.fatalf(expr, "operator %s is not implemented yet", expr.Op)
+	return nil
+}
+
+// floatPredicates are the predicates of the comparisons of floating-point
+// numbers. Only != holds when an operand is NaN.
+var floatPredicates = map[token.Token]enum.FPred{
+	token.LSS: enum.FPredOLT,
+	token.GTR: enum.FPredOGT,
+	token.LEQ: enum.FPredOLE,
+	token.GEQ: enum.FPredOGE,
+	token.EQL: enum.FPredOEQ,
+	token.NEQ: enum.FPredUNE,
 }
 
-func (v *Visitor) visitOperand(ctx *parser.OperandContext) value.Value {
-	if name := ctx.OperandName(); name != nil {
-		return v.visitOperandName(name.(*parser.OperandNameContext))
+// intPredicates are the predicates of the comparisons of signed integers and
+// of the equality of any scalar.
+var intPredicates = map[token.Token]enum.IPred{
+	token.LSS: enum.IPredSLT,
+	token.GTR: enum.IPredSGT,
+	token.LEQ: enum.IPredSLE,
+	token.GEQ: enum.IPredSGE,
+	token.EQL: enum.IPredEQ,
+	token.NEQ: enum.IPredNE,
+}
+
+var unsignedPredicates = map[token.Token]enum.IPred{
+	token.LSS: enum.IPredULT,
+	token.GTR: enum.IPredUGT,
+	token.LEQ: enum.IPredULE,
+	token.GEQ: enum.IPredUGE,
+	token.EQL: enum.IPredEQ,
+	token.NEQ: enum.IPredNE,
+}
+
+// visitDivision lowers the integer division or remainder of expr. A zero
+// divisor panics. Dividing the most negative value by -1 overflows in Go
+// and traps in LLVM, so a divisor of -1 negates instead.
+func (v *Visitor) visitDivision(expr *ast.BinaryExpr, x, y value.Value, unsigned bool) value.Value {
+	typ := x.Type().(*types.IntType)
+
+	divisor, isConst := y.(*constant.Int)
+	if !isConst {
+		isZero := v.curBlock.NewICmp(enum.IPredEQ, y, constant.NewInt(typ, 0))
+		v.panicIf(isZero, expr, "integer divide by zero")
 	}
 
-	if literal := ctx.Literal(); literal != nil {
-		return v.visitLiteral(literal.(*parser.LiteralContext))
+	switch {
+	case unsigned && expr.Op == token.QUO:
+		return v.curBlock.NewUDiv(x, y)
+	case unsigned:
+		return v.curBlock.NewURem(x, y)
+	case isConst && divisor.X.Int64() != -1:
+		if expr.Op == token.QUO {
+			return v.curBlock.NewSDiv(x, y)
+		}
+		return v.curBlock.NewSRem(x, y)
 	}
 
-	panic(fmt.Errorf("unknown operand"))
+	minusOne := v.curBlock.NewICmp(enum.IPredEQ, y, constant.NewInt(typ, -1))
+	y = v.curBlock.NewSelect(minusOne, constant.NewInt(typ, 1), y)
+
+	if expr.Op == token.QUO {
+		negated := v.curBlock.NewSub(constant.NewInt(typ, 0), x)
+		return v.curBlock.NewSelect(minusOne, negated, v.curBlock.NewSDiv(x, y))
+	}
+	return v.curBlock.NewSelect(minusOne, constant.NewInt(typ, 0), v.curBlock.NewSRem(x, y))
 }
 
-func (v *Visitor) visitOperandName(ctx *parser.OperandNameContext) value.Value {
-	name := ctx.IDENTIFIER().GetText()
-	//variable, ok := v.variables[name]
-	variable, ok := v.contextBlockVariables[len(v.contextBlockVariables)-1][name]
-	if !ok {
-		panic(fmt.Errorf("unknown variable: %s", name))
+// visitShift lowers a shift with the semantics of Go: shifting by the width
+// of the value or more gives 0, or -1 for a negative value shifted right.
+// LLVM leaves such shifts undefined. A negative count panics.
+func (v *Visitor) visitShift(expr *ast.BinaryExpr, x, count value.Value, unsigned bool) value.Value {
+	op := expr.Op
+	typ := x.Type().(*types.IntType)
+	width := int64(typ.BitSize)
+
+	if _, ok := count.(*constant.Int); !ok && !v.isUnsigned(v.typeOf(expr.Y)) {
+		isNegative := v.curBlock.NewICmp(enum.IPredSLT, count, constant.NewInt(count.Type().(*types.IntType), 0))
+		v.panicIf(isNegative, expr, "negative shift amount")
 	}
 
-	load := v.curBlock.NewLoad(variable.Type, variable.Value)
+	if c, ok := count.(*constant.Int); ok && c.X.IsInt64() && c.X.Int64() < width {
+		count = v.shiftCount(count, typ)
+		switch {
+		case op == token.SHL:
+			return v.curBlock.NewShl(x, count)
+		case unsigned:
+			return v.curBlock.NewLShr(x, count)
+		}
+		return v.curBlock.NewAShr(x, count)
+	}
 
-	return load
+	tooLarge := v.curBlock.NewICmp(enum.IPredUGE, count, constant.NewInt(count.Type().(*types.IntType), width))
+	count = v.shiftCount(count, typ)
+
+	if op == token.SHR && !unsigned {
+		// Shifting by width-1 fills the value with its sign.
+		count = v.curBlock.NewSelect(tooLarge, constant.NewInt(typ, width-1), count)
+		return v.curBlock.NewAShr(x, count)
+	}
+
+	var shifted value.Value
+	if op == token.SHL {
+		shifted = v.curBlock.NewShl(x, count)
+	} else {
+		shifted = v.curBlock.NewLShr(x, count)
+	}
+	return v.curBlock.NewSelect(tooLarge, constant.NewInt(typ, 0), shifted)
 }
 
-func (v *Visitor) visitLiteral(ctx *parser.LiteralContext) value.Value {
-	if basicLit := ctx.BasicLit(); basicLit != nil {
-		return v.visitBasicLit(basicLit.(*parser.BasicLitContext))
+// shiftCount converts the count of a shift to the type of the shifted value,
+// LLVM shifts take operands of the same type.
+func (v *Visitor) shiftCount(count value.Value, typ types.Type) value.Value {
+	countSize := count.Type().(*types.IntType).BitSize
+	valueSize := typ.(*types.IntType).BitSize
+
+	switch {
+	case countSize > valueSize:
+		return v.curBlock.NewTrunc(count, typ)
+	case countSize < valueSize:
+		return v.curBlock.NewZExt(count, typ)
 	}
 
-	panic("unknown literal")
+	return count
 }
 
-func (v *Visitor) visitBasicLit(ctx *parser.BasicLitContext) value.Value {
-	if str := ctx.String_(); str != nil {
+func (v *Visitor) visitCallExpr(call *ast.CallExpr) value.Value {
+	if v.Info.Types[call.Fun].IsType() {
+		return v.visitConversion(call)
+	}
+
+	ident, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		v.fatalf(call.Fun, "calls of non-names are not implemented yet")
+	}
 
+	// The builtins are told apart by their object, functions of the
+	// package may reuse their names.
+	builtin, isBuiltin := v.Info.Uses[ident].(*check.Builtin)
+	if isBuiltin {
+		switch builtin.Name() {
+		case "len", "cap":
+			return v.visitLenCall(call, ident.Name)
+		case "make":
+			return v.visitMakeCall(call)
+		case "append":
+			return v.visitAppendCall(call)
+		case "copy":
+			return v.visitCopyCall(call)
+		case "delete":
+			return v.visitDeleteCall(call)
+		}
 	}
 
-	if integer := ctx.Integer(); integer != nil {
-		literal := integer.(*parser.IntegerContext).DECIMAL_LIT().GetText()
-		constantValue, _ := strconv.Atoi(literal)
-		return constant.NewInt(types.I64, int64(constantValue))
+	var argumentsValues []value.Value
+	if tuple, ok := v.tupleArg(call); ok {
+		// f(g()) passes the results of g.
+		argumentsValues = v.visitValues(call.Args, tuple.Len())
+	} else {
+		for _, arg := range call.Args {
+			argumentsValues = append(argumentsValues, v.visitExpression(arg))
+		}
 	}
 
-	if floatLit := ctx.FLOAT_LIT(); floatLit != nil {
-		panic("float is not realised yet")
+	if sig, ok := v.typeOf(call.Fun).(*check.Signature); ok && sig.Variadic() && !call.Ellipsis {
+		argumentsValues = v.packVariadicArgs(sig, argumentsValues)
 	}
 
-	if stringLit := ctx.String_(); stringLit != nil {
-		var constString *ir.Global
-		valueStr := ctx.GetText()
-		valueStr = valueStr[1:len(valueStr)-1]
+	if isBuiltin {
+		return v.visitPrintCall(call, builtin.Name(), argumentsValues)
+	}
+	return v.visitFuncCall(call, ident.Name, argumentsValues)
+}
+
+// packVariadicArgs replaces the arguments of the variadic parameter of sig
+// by a slice holding them. The slice is nil without arguments.
+func (v *Visitor) packVariadicArgs(sig *check.Signature, args []value.Value) []value.Value {
+	n := sig.Params().Len()
+	sliceType := v.goType(sig.Params().At(n - 1).Type()).(*_type.SliceType)
+
+	extra := args[n-1:]
+	if len(extra) == 0 {
+		return append(args, constant.NewZeroInitializer(sliceType.LLVM()))
+	}
 
+	length := constant.NewInt(types.I64, int64(len(extra)))
+	array := v.newArray(sliceType.Elem, length)
+	for i, arg := range extra {
+		elemPtr := v.curBlock.NewGetElementPtr(pointer.ElemType(array), array, constant.NewInt(types.I64, int64(i)))
+		v.store(arg, elemPtr)
+	}
 
-		constString = v.Module.NewGlobalDef(strings.NextStringName(), strings.Constant(valueStr))
-		constString.Immutable = true
+	var slice value.Value = constant.NewUndef(sliceType.LLVM())
+	slice = v.curBlock.NewInsertValue(slice, array, 0)
+	slice = v.curBlock.NewInsertValue(slice, length, 1)
+	slice = v.curBlock.NewInsertValue(slice, length, 2)
 
-		alloc := v.curBlock.NewAlloca(_type.String.LLVM())
+	return append(args[:n-1], slice)
+}
 
-		// Save length of the string
-		lenItem := v.curBlock.NewGetElementPtr(pointer.ElemType(alloc), alloc, constant.NewInt(types.I32, 0), constant.NewInt(types.I32, 0))
-		v.curBlock.NewStore(constant.NewInt(types.I64, int64(len(valueStr))), lenItem)
+// tupleArg returns the results passed by a call f(g()), it reports false
+// for other calls.
+func (v *Visitor) tupleArg(call *ast.CallExpr) (*check.Tuple, bool) {
+	if len(call.Args) != 1 {
+		return nil, false
+	}
+	tuple, ok := v.typeOf(call.Args[0]).(*check.Tuple)
+	return tuple, ok
+}
 
-		// Save i8* version of string
-		strItem := v.curBlock.NewGetElementPtr(pointer.ElemType(alloc), alloc, constant.NewInt(types.I32, 0), constant.NewInt(types.I32, 1))
-		v.curBlock.NewStore(strings.Toi8Ptr(v.curBlock, constString), strItem)
+func (v *Visitor) visitIdent(ident *ast.Ident) value.Value {
+	// nil has the type of the slice or the map it is used as.
+	if _, ok := v.Info.Uses[ident].(*check.Nil); ok {
+		return constant.NewZeroInitializer(v.llvmType(v.typeOf(ident)))
+	}
 
-		return v.curBlock.NewLoad(pointer.ElemType(alloc), alloc)
+	variable, ok := v.getVar(ident.Name)
+	if !ok {
+		v.fatalf(ident, "undefined: %s", ident.Name)
 	}
 
-	panic("unknown basic literal")
+	load := v.load(variable.Type, variable.Value)
+
+	return load
 }
 
+// visitConversion lowers a conversion between numeric types. Integers are
+// sign extended and converted from and to floating-point numbers as signed
+// values when their type is signed.
+func (v *Visitor) visitConversion(call *ast.CallExpr) value.Value {
+	val := v.visitExpression(call.Args[0])
+	from := v.typeOf(call.Args[0])
+	to := v.typeOf(call)
+	toType := v.llvmType(to)
+
+	switch fromType := val.Type().(type) {
+	case *types.IntType:
+		switch toType := toType.(type) {
+		case *types.IntType:
+			switch {
+			case fromType.BitSize > toType.BitSize:
+				return v.curBlock.NewTrunc(val, toType)
+			case fromType.BitSize < toType.BitSize && v.isUnsigned(from):
+				return v.curBlock.NewZExt(val, toType)
+			case fromType.BitSize < toType.BitSize:
+				return v.curBlock.NewSExt(val, toType)
+			}
+		case *types.FloatType:
+			if v.isUnsigned(from) {
+				return v.curBlock.NewUIToFP(val, toType)
+			}
+			return v.curBlock.NewSIToFP(val, toType)
+		}
+
+	case *types.FloatType:
+		switch toType := toType.(type) {
+		case *types.IntType:
+			if v.isUnsigned(to) {
+				return v.curBlock.NewFPToUI(val, toType)
+			}
+			return v.curBlock.NewFPToSI(val, toType)
+		case *types.FloatType:
+			switch {
+			case fromType.Kind == types.FloatKindFloat && toType.Kind == types.FloatKindDouble:
+				return v.curBlock.NewFPExt(val, toType)
+			case fromType.Kind == types.FloatKindDouble && toType.Kind == types.FloatKindFloat:
+				return v.curBlock.NewFPTrunc(val, toType)
+			}
+		}
+	}
+
+	// Conversions between types lowered the same way.
+	return val
+}
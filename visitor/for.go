@@ -1,25 +1,28 @@
 package visitor
 
 import (
-	"nano-go/parser"
+	"go/token"
+
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/ast"
+	"nano-go/check"
 	"nano-go/visitor/name"
 )
 
-func (v *Visitor) visitForStmt(ctx *parser.ForStmtContext) {
-	if ctx.Expression() != nil {
-		v.forExpression(ctx)
-		return
-	}
-
-	if ctx.ForClause() != nil {
-		v.fullFor(ctx)
+func (v *Visitor) visitForStmt(stmt *ast.ForStmt) {
+	if stmt.Init == nil && stmt.Post == nil && stmt.Cond != nil {
+		v.forExpression(stmt)
 		return
 	}
 
-	panic("some strange for cycle")
+	v.fullFor(stmt)
 }
 
-func (v *Visitor) forExpression(ctx *parser.ForStmtContext) {
+func (v *Visitor) forExpression(stmt *ast.ForStmt) {
 	condBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-cond")
 	bodyBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-body")
 	afterBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-after-for")
@@ -28,59 +31,204 @@ func (v *Visitor) forExpression(ctx *parser.ForStmtContext) {
 
 	// FOR cond
 	v.curBlock = condBlock
-	cond := v.visitExpression(ctx.Expression().(*parser.ExpressionContext))
+	cond := v.visitExpression(stmt.Cond)
 	v.curBlock.NewCondBr(cond, bodyBlock, afterBlock)
 
 	// body
 	v.curBlock = bodyBlock
-	v.visitBlock(ctx.Block().(*parser.BlockContext))
-	v.curBlock.NewBr(condBlock)
+	v.pushBranchTarget(afterBlock, condBlock)
+	v.visitBlock(stmt.Body)
+	v.popBranchTarget()
+	if v.curBlock.Term == nil {
+		v.curBlock.NewBr(condBlock)
+	}
 
 	v.curBlock = afterBlock
 }
 
-func (v *Visitor) fullFor(ctx *parser.ForStmtContext) {
+func (v *Visitor) fullFor(stmt *ast.ForStmt) {
 	bodyBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-body")
 	condBlock := bodyBlock
 	afterBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-after-for")
 
-	forClause := ctx.ForClause().(*parser.ForClauseContext)
-	statementNum := 0
+	v.pushVariablesStack()
+	defer v.popVariablesStack()
 
 	// init step
-	if forClause.GetInitStmt() != nil {
-		initStmt := forClause.SimpleStmt(statementNum)
-		statementNum++
-		v.visitSimpleStmt(initStmt.(*parser.SimpleStmtContext))
+	if stmt.Init != nil {
+		v.visitSimpleStmt(stmt.Init)
 	}
 
-	if forClause.Expression() != nil {
+	if stmt.Cond != nil {
 		condBlock = v.curBlock.Parent.NewBlock(name.BlockName() + "-cond")
 		v.curBlock.NewBr(condBlock)
 
 		// cond step
 		v.curBlock = condBlock
-		cond := v.visitExpression(forClause.Expression().(*parser.ExpressionContext))
+		cond := v.visitExpression(stmt.Cond)
 		v.curBlock.NewCondBr(cond, bodyBlock, afterBlock)
 	} else {
 		v.curBlock.NewBr(bodyBlock)
 	}
 
+	// continue runs the post statement before the next iteration.
+	continueBlock := condBlock
+	if stmt.Post != nil {
+		continueBlock = v.curBlock.Parent.NewBlock(name.BlockName() + "-after-body")
+	}
+
 	// body
 	v.curBlock = bodyBlock
-	v.visitBlock(ctx.Block().(*parser.BlockContext))
-
-	if forClause.GetPostStmt() != nil {
-		afterBodyBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-after-body")
-		postStmt := forClause.SimpleStmt(statementNum)
+	v.pushBranchTarget(afterBlock, continueBlock)
+	v.visitBlock(stmt.Body)
+	v.popBranchTarget()
+	if v.curBlock.Term == nil {
+		v.curBlock.NewBr(continueBlock)
+	}
 
-		v.curBlock.NewBr(afterBodyBlock)
-		v.curBlock = afterBodyBlock
-		v.visitSimpleStmt(postStmt.(*parser.SimpleStmtContext))
+	if stmt.Post != nil {
+		v.curBlock = continueBlock
+		v.visitSimpleStmt(stmt.Post)
 		v.curBlock.NewBr(condBlock)
-	} else {
+	}
+
+	v.curBlock = afterBlock
+}
+
+// visitRangeStmt lowers a range loop. A hidden index counts the iterations,
+// the iteration variables are new variables in every iteration as of Go
+// 1.22, so changing them in the body does not change the loop.
+func (v *Visitor) visitRangeStmt(stmt *ast.RangeStmt) {
+	v.pushVariablesStack()
+	defer v.popVariablesStack()
+
+	x := v.visitExpression(stmt.X)
+	wantValue := stmt.Value != nil && !ast.IsBlank(stmt.Value)
+
+	// end is the end of the index, next computes the iteration values for
+	// the index i and the index of the next iteration.
+	var end value.Value
+	var next func(i value.Value) (key, val, nextIndex value.Value)
+	indexType := types.I64
+	unsigned := false
+
+	switch t := v.typeOf(stmt.X).Underlying().(type) {
+	case *check.Basic:
+		if t.Info()&check.IsString != 0 {
+			end = v.curBlock.NewExtractValue(x, 0)
+			data := v.curBlock.NewExtractValue(x, 1)
+			next = func(i value.Value) (value.Value, value.Value, value.Value) {
+				p := v.curBlock.NewGetElementPtr(types.I8, data, i)
+				res := v.curBlock.NewCall(v.decodeRuneFunc(), p, v.curBlock.NewSub(end, i))
+				r := v.curBlock.NewExtractValue(res, 0)
+				width := v.curBlock.NewExtractValue(res, 1)
+				return i, r, v.curBlock.NewAdd(i, width)
+			}
+			break
+		}
+
+		end = x
+		indexType = x.Type().(*types.IntType)
+		unsigned = v.isUnsigned(t)
+		next = func(i value.Value) (value.Value, value.Value, value.Value) {
+			return i, nil, v.curBlock.NewAdd(i, constant.NewInt(indexType, 1))
+		}
+
+	case *check.Array:
+		// The loop ranges over a copy of the array.
+		arrayType := v.llvmType(t)
+		arr := v.alloca(arrayType, "")
+		v.store(x, arr)
+		end = constant.NewInt(types.I64, t.Len())
+		elemType := v.llvmType(t.Elem())
+		next = func(i value.Value) (value.Value, value.Value, value.Value) {
+			var elem value.Value
+			if wantValue {
+				ptr := v.curBlock.NewGetElementPtr(arrayType, arr, constant.NewInt(types.I64, 0), i)
+				elem = v.load(elemType, ptr)
+			}
+			return i, elem, v.curBlock.NewAdd(i, constant.NewInt(types.I64, 1))
+		}
+
+	case *check.Slice:
+		end = v.curBlock.NewExtractValue(x, 1)
+		data := v.curBlock.NewExtractValue(x, 0)
+		elemType := v.llvmType(t.Elem())
+		next = func(i value.Value) (value.Value, value.Value, value.Value) {
+			var elem value.Value
+			if wantValue {
+				ptr := v.curBlock.NewGetElementPtr(memoryType(elemType), data, i)
+				elem = v.load(elemType, ptr)
+			}
+			return i, elem, v.curBlock.NewAdd(i, constant.NewInt(types.I64, 1))
+		}
+
+	case *check.Map:
+		v.visitMapRange(stmt, x, t)
+		return
+
+	default:
+		v.fatalf(stmt.X, "range over %s is not implemented yet", v.typeOf(stmt.X))
+	}
+
+	index := v.alloca(indexType, "")
+	v.curBlock.NewStore(constant.NewInt(indexType, 0), index)
+
+	condBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-cond")
+	bodyBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-body")
+	afterBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-after-for")
+
+	v.curBlock.NewBr(condBlock)
+
+	// cond
+	v.curBlock = condBlock
+	i := v.curBlock.NewLoad(indexType, index)
+	pred := enum.IPredSLT
+	if unsigned {
+		pred = enum.IPredULT
+	}
+	v.curBlock.NewCondBr(v.curBlock.NewICmp(pred, i, end), bodyBlock, afterBlock)
+
+	// body, the index is advanced before it runs so that continue goes
+	// straight to the condition.
+	v.curBlock = bodyBlock
+	key, val, nextIndex := next(i)
+	v.curBlock.NewStore(nextIndex, index)
+
+	v.rangeAssign(stmt, stmt.Key, key)
+	if wantValue {
+		v.rangeAssign(stmt, stmt.Value, val)
+	}
+
+	v.pushBranchTarget(afterBlock, condBlock)
+	v.visitBlock(stmt.Body)
+	v.popBranchTarget()
+	if v.curBlock.Term == nil {
 		v.curBlock.NewBr(condBlock)
 	}
 
 	v.curBlock = afterBlock
-}
\ No newline at end of file
+}
+
+// rangeAssign declares or assigns the iteration variable lhs of stmt, a
+// missing or blank one is skipped.
+func (v *Visitor) rangeAssign(stmt *ast.RangeStmt, lhs ast.Expr, val value.Value) {
+	if lhs == nil || ast.IsBlank(lhs) {
+		return
+	}
+
+	if stmt.Tok == token.ASSIGN {
+		v.store(val, v.address(lhs))
+		return
+	}
+
+	ident := lhs.(*ast.Ident)
+	alloca := v.alloca(val.Type(), ident.Name)
+	v.store(val, alloca)
+
+	v.setVar(ident.Name, Value{
+		Value:      alloca,
+		Type:       val.Type(),
+		IsVariable: true,
+	})
+}
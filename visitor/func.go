@@ -1,129 +1,192 @@
 package visitor
 
 import (
-	"fmt"
-
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/types"
 	"github.com/llir/llvm/ir/value"
 
-	"nano-go/parser"
+	"nano-go/ast"
+	"nano-go/check"
 	"nano-go/visitor/name"
 )
 
 const paramNamePrefix = "param_"
 
-func (v *Visitor) visitMain(ctx *parser.FunctionDeclContext) {
+func (v *Visitor) visitMain(decl *ast.FuncDecl) {
 	f := v.Module.NewFunc("main", types.I32)
+	v.sig = v.Info.Defs[decl.Name].Type().(*check.Signature)
+	v.results = nil
 	v.curBlock = f.NewBlock(name.BlockName())
 
 	v.pushVariablesStack()
 
-	block := ctx.Block()
-	v.visitBlock(block.(*parser.BlockContext))
+	v.initPkgVars()
 
-	v.curBlock.NewRet(constant.NewInt(types.I32, 0))
-}
+	v.visitBlock(decl.Body)
 
-func (v *Visitor) getParamType(pType string) types.Type {
-	switch pType {
-	case "int64":
-		return types.I64
-	case "int32":
-		return types.I32
+	if v.curBlock.Term == nil {
+		v.retVoid()
 	}
-
-	panic(fmt.Errorf("unknown type: %s", pType))
 }
 
-func (v *Visitor) visitType_(ctx *parser.Type_Context) types.Type {
-	t := ctx.TypeName().(*parser.TypeNameContext).IDENTIFIER().GetText()
-	llvmType := v.getParamType(t)
-
-	return llvmType
+// retVoid returns from a function without results. main returns the exit
+// status 0 to the C runtime.
+func (v *Visitor) retVoid() {
+	if types.Equal(v.curBlock.Parent.Sig.RetType, types.Void) {
+		v.curBlock.NewRet(nil)
+		return
+	}
+	v.curBlock.NewRet(constant.NewInt(types.I32, 0))
 }
 
-func (v *Visitor) getParameters(ctx *parser.SignatureContext) []*ir.Param {
+func (v *Visitor) getParameters(decl *ast.FuncDecl, sig *check.Signature) []*ir.Param {
 	var params []*ir.Param
 
-	parameters := ctx.Parameters().(*parser.ParametersContext)
-	for _, parameterI := range parameters.AllParameterDecl() {
-		parameter := parameterI.(*parser.ParameterDeclContext)
-
-		identifierList := parameter.IdentifierList().(*parser.IdentifierListContext)
-		paramName := identifierList.IDENTIFIER(0).GetText()
+	for i := 0; i < sig.Params().Len(); i++ {
+		param := sig.Params().At(i)
 
-		paramType := parameter.Type_().(*parser.Type_Context)
-		llvmType:= v.visitType_(paramType)
+		// Unnamed parameters are numbered by LLVM.
+		paramName := ""
+		if param.Name() != "" && param.Name() != "_" {
+			paramName = paramNamePrefix + param.Name()
+		}
 
-		params = append(params, ir.NewParam(paramNamePrefix + paramName, llvmType))
+		params = append(params, ir.NewParam(paramName, v.llvmType(param.Type())))
 	}
 
 	return params
 }
 
-func (v *Visitor) getReturnTypes(ctx *parser.SignatureContext) []types.Type {
-	result := ctx.Result()
-	if result == nil {
+func (v *Visitor) getReturnTypes(decl *ast.FuncDecl, sig *check.Signature) []types.Type {
+	results := sig.Results()
+	if results.Len() == 0 {
 		return []types.Type{}
 	}
 
-	type_ := ctx.Result().(*parser.ResultContext).Type_()
-	llvmType := v.visitType_(type_.(*parser.Type_Context))
-	return []types.Type{llvmType}
+	var returnTypes []types.Type
+	for i := 0; i < results.Len(); i++ {
+		returnTypes = append(returnTypes, v.llvmType(results.At(i).Type()))
+	}
+
+	return returnTypes
 }
 
-func (v *Visitor) visitFunc(ctx *parser.FunctionDeclContext) {
-	v.pushVariablesStack()
-	fName := ctx.IDENTIFIER().GetText()
-	signature := ctx.Signature().(*parser.SignatureContext)
+// pkgSymbol returns the name in the module of the package-level object name.
+// It is qualified by the package like Go does, the names of the C functions
+// the runtime calls cannot be taken.
+func pkgSymbol(name string) string {
+	return "main." + name
+}
+
+// declareFunc adds the function of decl to the module, its body is visited
+// by visitFunc.
+func (v *Visitor) declareFunc(decl *ast.FuncDecl) {
+	defer v.recoverStatement()
 
-	params := v.getParameters(signature)
+	fName := decl.Name.Name
+
+	sig := v.Info.Defs[decl.Name].Type().(*check.Signature)
+	params := v.getParameters(decl, sig)
 
 	var funcRetType types.Type = types.Void
-	returnTypes := v.getReturnTypes(signature)
-	if len(returnTypes) == 1 {
+	returnTypes := v.getReturnTypes(decl, sig)
+	switch len(returnTypes) {
+	case 0:
+	case 1:
 		funcRetType = returnTypes[0]
+	default:
+		// Multiple results are returned in a struct.
+		funcRetType = types.NewStruct(returnTypes...)
 	}
 
-	f := v.Module.NewFunc(fName, funcRetType, params...)
+	f := v.Module.NewFunc(pkgSymbol(fName), funcRetType, params...)
 	v.pkgVars[fName] = Value{
 		Value: f,
-		Type: f.Type(),
+		Type:  f.Type(),
+	}
+}
+
+func (v *Visitor) visitFunc(decl *ast.FuncDecl) {
+	fn, ok := v.pkgVars[decl.Name.Name]
+	if !ok {
+		// The declaration has an error.
+		return
 	}
+	f := fn.Value.(*ir.Func)
+
+	v.pushVariablesStack()
+
+	sig := v.Info.Defs[decl.Name].Type().(*check.Signature)
+	v.sig = sig
+
 	v.curBlock = f.NewBlock(name.BlockName())
 
-	for _, param := range f.Params {
-		alloca := v.curBlock.NewAlloca(param.Type())
-		allocaName := param.Name()[6:]
-		alloca.SetName(allocaName)
-		v.curBlock.NewStore(param, alloca)
+	for i, param := range f.Params {
+		paramName := sig.Params().At(i).Name()
+		if paramName == "" || paramName == "_" {
+			continue
+		}
+
+		alloca := v.alloca(param.Type(), paramName)
+		v.store(param, alloca)
 
-		//v.variables[param.Name()] = param
-		v.setVar(allocaName, Value{
-			Value: alloca,
-			Type: param.Type(),
+		v.setVar(paramName, Value{
+			Value:      alloca,
+			Type:       param.Type(),
 			IsVariable: true,
 		})
 	}
 
-	block := ctx.Block()
-	v.visitBlock(block.(*parser.BlockContext))
+	v.declareResults(sig)
 
-	if len(returnTypes) == 0 {
-		v.curBlock.NewRet(nil)
+	v.visitBlock(decl.Body)
+
+	switch {
+	case v.curBlock.Term != nil:
+	case sig.Results().Len() == 0:
+		v.retVoid()
+	default:
+		// The body ends in a terminating statement, the block after it
+		// cannot be reached.
+		v.curBlock.NewUnreachable()
 	}
 }
 
-func (v *Visitor) visitFuncCall(name string, args []value.Value) value.Value {
-	switch name {
-	case "Print":
-		return v.printFuncCall(args)
-	case "Printf":
-		args[0] = v.curBlock.NewExtractValue(args[0], 1)
+// declareResults allocates the named results of sig in the entry block with
+// their zero values, a bare return returns them.
+func (v *Visitor) declareResults(sig *check.Signature) {
+	v.results = nil
+
+	results := sig.Results()
+	if results.Len() == 0 || results.At(0).Name() == "" {
+		return
+	}
+
+	for i := 0; i < results.Len(); i++ {
+		res := results.At(i)
+		typ := v.goType(res.Type())
+
+		alloca := v.alloca(typ.LLVM(), res.Name())
+		typ.Zero(v.curBlock, alloca)
+
+		result := Value{
+			Value:      alloca,
+			Type:       typ.LLVM(),
+			IsVariable: true,
+		}
+		v.results = append(v.results, result)
+		if res.Name() != "_" {
+			v.setVar(res.Name(), result)
+		}
+	}
+}
+
+func (v *Visitor) visitFuncCall(call *ast.CallExpr, name string, args []value.Value) value.Value {
+	fn, ok := v.pkgVars[name]
+	if !ok {
+		v.fatalf(call.Fun, "undefined: %s", name)
 	}
-	fn := v.pkgVars[name]
 
 	return v.curBlock.NewCall(fn.Value, args...)
-}
\ No newline at end of file
+}
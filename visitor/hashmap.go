@@ -0,0 +1,702 @@
+package visitor
+
+import (
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/check"
+	"nano-go/visitor/name"
+)
+
+// The maps are hash tables of the runtime with open addressing and linear
+// probing. A table has a power of two slots, a slot holds a state byte, the
+// key and the value, each at an offset aligned to 8 bytes. A deleted entry
+// leaves a tombstone until the table is rebuilt, which happens when three
+// quarters of the slots are used. The functions of the runtime are generated
+// by the first map needing them.
+
+// The states of the slots.
+const (
+	slotEmpty = iota
+	slotFull
+	slotDeleted
+)
+
+// The fields of runtime.hmap, the header of a table.
+const (
+	hmapCount    = iota // number of entries
+	hmapUsed            // number of slots that are not empty
+	hmapCap             // number of slots
+	hmapSlots           // the slots
+	hmapKeySize         // size of the keys
+	hmapValOff          // offset of the values in the slots
+	hmapSlotSize        // size of a slot
+	hmapHash            // hash function of the keys
+	hmapEqual           // equality function of the keys
+)
+
+// The fields of runtime.hiter, the state of a range loop over a map.
+const (
+	hiterSlots = iota // the slots of the map when the loop started
+	hiterCap          // their number
+	hiterIndex        // index of the next slot
+	hiterKey          // address of the key of the current entry
+	hiterVal          // address of its value
+)
+
+const (
+	// hmapSize is the size of runtime.hmap.
+	hmapSize = 72
+	// minMapCap is the number of slots of a new table.
+	minMapCap = 8
+	// slotKeyOff is the offset of the key in a slot.
+	slotKeyOff = 8
+
+	// fnvOffset and fnvPrime are the parameters of the FNV-1a hash,
+	// fnvOffset is 14695981039346656037.
+	fnvOffset = -3750763034362895579
+	fnvPrime  = 1099511628211
+)
+
+var (
+	bytePtrType = types.NewPointer(types.I8)
+
+	// hashFuncType and equalFuncType are the types of the hash and
+	// equality functions of the keys, which take keys by address.
+	hashFuncType  = types.NewPointer(types.NewFunc(types.I64, bytePtrType))
+	equalFuncType = types.NewPointer(types.NewFunc(types.I1, bytePtrType, bytePtrType))
+)
+
+func i64(x int64) constant.Constant {
+	return constant.NewInt(types.I64, x)
+}
+
+// field returns the address of the field i of the struct of type t at ptr.
+func field(block *ir.Block, t *types.StructType, ptr value.Value, i int64) value.Value {
+	return block.NewGetElementPtr(t, ptr, constant.NewInt(types.I32, 0), constant.NewInt(types.I32, i))
+}
+
+// loadField loads the field i of the struct of type t at ptr.
+func loadField(block *ir.Block, t *types.StructType, ptr value.Value, i int64) value.Value {
+	return block.NewLoad(t.Fields[i], field(block, t, ptr, i))
+}
+
+// align8 rounds the size n up to a multiple of 8.
+func align8(block *ir.Block, n value.Value) value.Value {
+	return block.NewAnd(block.NewAdd(n, i64(7)), i64(-8))
+}
+
+// newFuncBlock adds a block to the runtime function fn.
+func newFuncBlock(fn *ir.Func, suffix string) *ir.Block {
+	return fn.NewBlock(name.BlockName() + suffix)
+}
+
+// hmap returns the type of the table headers, it is defined by the first
+// map.
+func (v *Visitor) hmap() *types.StructType {
+	if v.hmapType == nil {
+		v.hmapType = v.Module.NewTypeDef("runtime.hmap", types.NewStruct(
+			types.I64,
+			types.I64,
+			types.I64,
+			bytePtrType,
+			types.I64,
+			types.I64,
+			types.I64,
+			hashFuncType,
+			equalFuncType,
+		)).(*types.StructType)
+	}
+	return v.hmapType
+}
+
+// hiter returns the type of the states of the range loops over maps, it is
+// defined by the first one.
+func (v *Visitor) hiter() *types.StructType {
+	if v.hiterType == nil {
+		v.hiterType = v.Module.NewTypeDef("runtime.hiter", types.NewStruct(
+			bytePtrType,
+			types.I64,
+			types.I64,
+			bytePtrType,
+			bytePtrType,
+		)).(*types.StructType)
+	}
+	return v.hiterType
+}
+
+// mapFunc returns the runtime function called name. It is declared with
+// the result ret and params the first time, and body generates its code;
+// the C functions have no body.
+func (v *Visitor) mapFunc(name string, ret types.Type, params []*ir.Param, body func(fn *ir.Func)) *ir.Func {
+	if fn, ok := v.mapFns[name]; ok {
+		return fn
+	}
+
+	fn := v.Module.NewFunc(name, ret, params...)
+	v.mapFns[name] = fn
+	if body != nil {
+		body(fn)
+	}
+	return fn
+}
+
+func (v *Visitor) memsetFunc() *ir.Func {
+	return v.mapFunc("memset", bytePtrType, []*ir.Param{
+		ir.NewParam("dst", bytePtrType),
+		ir.NewParam("c", types.I32),
+		ir.NewParam("n", types.I64),
+	}, nil)
+}
+
+func (v *Visitor) memcmpFunc() *ir.Func {
+	return v.mapFunc("memcmp", types.I32, []*ir.Param{
+		ir.NewParam("x", bytePtrType),
+		ir.NewParam("y", bytePtrType),
+		ir.NewParam("n", types.I64),
+	}, nil)
+}
+
+// makeMapFunc returns runtime.makemap, which allocates a table for hint
+// entries with keys and values of the given sizes.
+func (v *Visitor) makeMapFunc() *ir.Func {
+	keySize := ir.NewParam("keySize", types.I64)
+	valSize := ir.NewParam("valSize", types.I64)
+	hash := ir.NewParam("hash", hashFuncType)
+	equal := ir.NewParam("equal", equalFuncType)
+	hint := ir.NewParam("hint", types.I64)
+
+	return v.mapFunc("runtime.makemap", bytePtrType, []*ir.Param{keySize, valSize, hash, equal, hint}, func(fn *ir.Func) {
+		hmap := v.hmap()
+
+		entry := newFuncBlock(fn, "")
+		mem := entry.NewCall(v.calloc(), i64(1), i64(hmapSize))
+		h := entry.NewBitCast(mem, types.NewPointer(hmap))
+
+		valOff := entry.NewAdd(i64(slotKeyOff), align8(entry, keySize))
+		slotSize := entry.NewAdd(valOff, align8(entry, valSize))
+		entry.NewStore(keySize, field(entry, hmap, h, hmapKeySize))
+		entry.NewStore(valOff, field(entry, hmap, h, hmapValOff))
+		entry.NewStore(slotSize, field(entry, hmap, h, hmapSlotSize))
+		entry.NewStore(hash, field(entry, hmap, h, hmapHash))
+		entry.NewStore(equal, field(entry, hmap, h, hmapEqual))
+
+		// The slots are doubled from minMapCap until hint entries fit.
+		loop := newFuncBlock(fn, "-cap")
+		grow := newFuncBlock(fn, "-grow")
+		alloc := newFuncBlock(fn, "-alloc")
+		entry.NewBr(loop)
+
+		capacity := loop.NewPhi(ir.NewIncoming(i64(minMapCap), entry))
+		tooFull := loop.NewICmp(enum.IPredSGT, loop.NewMul(hint, i64(4)), loop.NewMul(capacity, i64(3)))
+		loop.NewCondBr(tooFull, grow, alloc)
+
+		capacity.Incs = append(capacity.Incs, ir.NewIncoming(grow.NewShl(capacity, i64(1)), grow))
+		grow.NewBr(loop)
+
+		alloc.NewStore(capacity, field(alloc, hmap, h, hmapCap))
+		alloc.NewStore(alloc.NewCall(v.calloc(), capacity, slotSize), field(alloc, hmap, h, hmapSlots))
+		alloc.NewRet(mem)
+	})
+}
+
+// mapProbeFunc returns runtime.mapprobe, which finds the slot of key in the
+// table of the map m: the one holding it, or else the one it is inserted
+// in, the first tombstone or the empty slot ending the search.
+func (v *Visitor) mapProbeFunc() *ir.Func {
+	m := ir.NewParam("m", bytePtrType)
+	key := ir.NewParam("key", bytePtrType)
+
+	return v.mapFunc("runtime.mapprobe", bytePtrType, []*ir.Param{m, key}, func(fn *ir.Func) {
+		hmap := v.hmap()
+
+		entry := newFuncBlock(fn, "")
+		h := entry.NewBitCast(m, types.NewPointer(hmap))
+		slots := loadField(entry, hmap, h, hmapSlots)
+		slotSize := loadField(entry, hmap, h, hmapSlotSize)
+		equal := loadField(entry, hmap, h, hmapEqual)
+		mask := entry.NewSub(loadField(entry, hmap, h, hmapCap), i64(1))
+		start := entry.NewAnd(entry.NewCall(loadField(entry, hmap, h, hmapHash), key), mask)
+
+		loop := newFuncBlock(fn, "-probe")
+		empty := newFuncBlock(fn, "-empty")
+		deleted := newFuncBlock(fn, "-deleted")
+		full := newFuncBlock(fn, "-full")
+		found := newFuncBlock(fn, "-found")
+		next := newFuncBlock(fn, "-next")
+		entry.NewBr(loop)
+
+		// free is the first tombstone on the way, nil until there is one.
+		i := loop.NewPhi(ir.NewIncoming(start, entry))
+		free := loop.NewPhi(ir.NewIncoming(constant.NewNull(bytePtrType), entry))
+		slot := loop.NewGetElementPtr(types.I8, slots, loop.NewMul(i, slotSize))
+		state := loop.NewLoad(types.I8, slot)
+		loop.NewSwitch(state, full,
+			ir.NewCase(constant.NewInt(types.I8, slotEmpty), empty),
+			ir.NewCase(constant.NewInt(types.I8, slotDeleted), deleted),
+		)
+
+		firstFree := func(block *ir.Block) value.Value {
+			noFree := block.NewICmp(enum.IPredEQ, free, constant.NewNull(bytePtrType))
+			return block.NewSelect(noFree, slot, free)
+		}
+
+		empty.NewRet(firstFree(empty))
+
+		deletedFree := firstFree(deleted)
+		deleted.NewBr(next)
+
+		isKey := full.NewCall(equal, full.NewGetElementPtr(types.I8, slot, i64(slotKeyOff)), key)
+		full.NewCondBr(isKey, found, next)
+		found.NewRet(slot)
+
+		nextFree := next.NewPhi(ir.NewIncoming(free, full), ir.NewIncoming(deletedFree, deleted))
+		nextIndex := next.NewAnd(next.NewAdd(i, i64(1)), mask)
+		next.NewBr(loop)
+
+		i.Incs = append(i.Incs, ir.NewIncoming(nextIndex, next))
+		free.Incs = append(free.Incs, ir.NewIncoming(nextFree, next))
+	})
+}
+
+// isFull reports whether the state of the slot at slot is slotFull.
+func isFull(block *ir.Block, slot value.Value) value.Value {
+	state := block.NewLoad(types.I8, slot)
+	return block.NewICmp(enum.IPredEQ, state, constant.NewInt(types.I8, slotFull))
+}
+
+// mapAccessFunc returns runtime.mapaccess, which returns the address of the
+// value of key in the map m, nil when m has no such entry.
+func (v *Visitor) mapAccessFunc() *ir.Func {
+	m := ir.NewParam("m", bytePtrType)
+	key := ir.NewParam("key", bytePtrType)
+
+	return v.mapFunc("runtime.mapaccess", bytePtrType, []*ir.Param{m, key}, func(fn *ir.Func) {
+		hmap := v.hmap()
+
+		entry := newFuncBlock(fn, "")
+		lookup := newFuncBlock(fn, "-lookup")
+		found := newFuncBlock(fn, "-found")
+		missing := newFuncBlock(fn, "-missing")
+		entry.NewCondBr(entry.NewICmp(enum.IPredEQ, m, constant.NewNull(bytePtrType)), missing, lookup)
+		missing.NewRet(constant.NewNull(bytePtrType))
+
+		slot := lookup.NewCall(v.mapProbeFunc(), m, key)
+		lookup.NewCondBr(isFull(lookup, slot), found, missing)
+
+		h := found.NewBitCast(m, types.NewPointer(hmap))
+		found.NewRet(found.NewGetElementPtr(types.I8, slot, loadField(found, hmap, h, hmapValOff)))
+	})
+}
+
+// mapAssignFunc returns runtime.mapassign, which returns the address of the
+// value of key in the map m, which is not nil. A missing entry is added
+// with the zero value, the table is rebuilt first when it is too full.
+func (v *Visitor) mapAssignFunc() *ir.Func {
+	m := ir.NewParam("m", bytePtrType)
+	key := ir.NewParam("key", bytePtrType)
+
+	return v.mapFunc("runtime.mapassign", bytePtrType, []*ir.Param{m, key}, func(fn *ir.Func) {
+		hmap := v.hmap()
+
+		entry := newFuncBlock(fn, "")
+		rebuild := newFuncBlock(fn, "-rebuild")
+		probe := newFuncBlock(fn, "-probe")
+		found := newFuncBlock(fn, "-found")
+		insert := newFuncBlock(fn, "-insert")
+
+		h := entry.NewBitCast(m, types.NewPointer(hmap))
+		used := loadField(entry, hmap, h, hmapUsed)
+		capacity := loadField(entry, hmap, h, hmapCap)
+		tooFull := entry.NewICmp(enum.IPredSGT,
+			entry.NewMul(entry.NewAdd(used, i64(1)), i64(4)),
+			entry.NewMul(capacity, i64(3)),
+		)
+		entry.NewCondBr(tooFull, rebuild, probe)
+
+		rebuild.NewCall(v.mapGrowFunc(), m)
+		rebuild.NewBr(probe)
+
+		slot := probe.NewCall(v.mapProbeFunc(), m, key)
+		valOff := loadField(probe, hmap, h, hmapValOff)
+		val := probe.NewGetElementPtr(types.I8, slot, valOff)
+		probe.NewCondBr(isFull(probe, slot), found, insert)
+
+		found.NewRet(val)
+
+		// A tombstone was already used.
+		wasEmpty := insert.NewICmp(enum.IPredEQ, insert.NewLoad(types.I8, slot), constant.NewInt(types.I8, slotEmpty))
+		usedPtr := field(insert, hmap, h, hmapUsed)
+		insert.NewStore(insert.NewAdd(insert.NewLoad(types.I64, usedPtr), insert.NewZExt(wasEmpty, types.I64)), usedPtr)
+		countPtr := field(insert, hmap, h, hmapCount)
+		insert.NewStore(insert.NewAdd(insert.NewLoad(types.I64, countPtr), i64(1)), countPtr)
+
+		insert.NewStore(constant.NewInt(types.I8, slotFull), slot)
+		keySize := loadField(insert, hmap, h, hmapKeySize)
+		insert.NewCall(v.memmoveFunc(), insert.NewGetElementPtr(types.I8, slot, i64(slotKeyOff)), key, keySize)
+		valSize := insert.NewSub(loadField(insert, hmap, h, hmapSlotSize), valOff)
+		insert.NewCall(v.memsetFunc(), val, constant.NewInt(types.I32, 0), valSize)
+		insert.NewRet(val)
+	})
+}
+
+// mapGrowFunc returns runtime.mapgrow, which rebuilds the table of the map
+// m without its tombstones. The new table has twice as many slots when more
+// than half of them would be used.
+func (v *Visitor) mapGrowFunc() *ir.Func {
+	m := ir.NewParam("m", bytePtrType)
+
+	return v.mapFunc("runtime.mapgrow", types.Void, []*ir.Param{m}, func(fn *ir.Func) {
+		hmap := v.hmap()
+
+		entry := newFuncBlock(fn, "")
+		h := entry.NewBitCast(m, types.NewPointer(hmap))
+		count := loadField(entry, hmap, h, hmapCount)
+		capacity := loadField(entry, hmap, h, hmapCap)
+		slots := loadField(entry, hmap, h, hmapSlots)
+		slotSize := loadField(entry, hmap, h, hmapSlotSize)
+
+		halfFull := entry.NewICmp(enum.IPredSGT, entry.NewMul(entry.NewAdd(count, i64(1)), i64(2)), capacity)
+		newCap := entry.NewSelect(halfFull, entry.NewShl(capacity, i64(1)), capacity)
+		entry.NewStore(newCap, field(entry, hmap, h, hmapCap))
+		entry.NewStore(entry.NewCall(v.calloc(), newCap, slotSize), field(entry, hmap, h, hmapSlots))
+		entry.NewStore(count, field(entry, hmap, h, hmapUsed))
+
+		// The entries are moved to the slots the new table probes.
+		loop := newFuncBlock(fn, "-cond")
+		body := newFuncBlock(fn, "-body")
+		move := newFuncBlock(fn, "-move")
+		next := newFuncBlock(fn, "-next")
+		done := newFuncBlock(fn, "-done")
+		entry.NewBr(loop)
+
+		i := loop.NewPhi(ir.NewIncoming(i64(0), entry))
+		loop.NewCondBr(loop.NewICmp(enum.IPredSLT, i, capacity), body, done)
+
+		slot := body.NewGetElementPtr(types.I8, slots, body.NewMul(i, slotSize))
+		body.NewCondBr(isFull(body, slot), move, next)
+
+		newSlot := move.NewCall(v.mapProbeFunc(), m, move.NewGetElementPtr(types.I8, slot, i64(slotKeyOff)))
+		move.NewCall(v.memmoveFunc(), newSlot, slot, slotSize)
+		move.NewBr(next)
+
+		nextIndex := next.NewAdd(i, i64(1))
+		next.NewBr(loop)
+		i.Incs = append(i.Incs, ir.NewIncoming(nextIndex, next))
+
+		done.NewRet(nil)
+	})
+}
+
+// mapDeleteFunc returns runtime.mapdelete, which removes the entry of key
+// from the map m if there is one.
+func (v *Visitor) mapDeleteFunc() *ir.Func {
+	m := ir.NewParam("m", bytePtrType)
+	key := ir.NewParam("key", bytePtrType)
+
+	return v.mapFunc("runtime.mapdelete", types.Void, []*ir.Param{m, key}, func(fn *ir.Func) {
+		hmap := v.hmap()
+
+		entry := newFuncBlock(fn, "")
+		lookup := newFuncBlock(fn, "-lookup")
+		remove := newFuncBlock(fn, "-remove")
+		done := newFuncBlock(fn, "-done")
+		entry.NewCondBr(entry.NewICmp(enum.IPredEQ, m, constant.NewNull(bytePtrType)), done, lookup)
+		done.NewRet(nil)
+
+		slot := lookup.NewCall(v.mapProbeFunc(), m, key)
+		lookup.NewCondBr(isFull(lookup, slot), remove, done)
+
+		remove.NewStore(constant.NewInt(types.I8, slotDeleted), slot)
+		h := remove.NewBitCast(m, types.NewPointer(hmap))
+		countPtr := field(remove, hmap, h, hmapCount)
+		remove.NewStore(remove.NewSub(remove.NewLoad(types.I64, countPtr), i64(1)), countPtr)
+		remove.NewRet(nil)
+	})
+}
+
+// mapLenFunc returns runtime.maplen, which returns the number of entries of
+// the map m.
+func (v *Visitor) mapLenFunc() *ir.Func {
+	m := ir.NewParam("m", bytePtrType)
+
+	return v.mapFunc("runtime.maplen", types.I64, []*ir.Param{m}, func(fn *ir.Func) {
+		hmap := v.hmap()
+
+		entry := newFuncBlock(fn, "")
+		isNil := newFuncBlock(fn, "-nil")
+		notNil := newFuncBlock(fn, "-not-nil")
+		entry.NewCondBr(entry.NewICmp(enum.IPredEQ, m, constant.NewNull(bytePtrType)), isNil, notNil)
+		isNil.NewRet(i64(0))
+
+		h := notNil.NewBitCast(m, types.NewPointer(hmap))
+		notNil.NewRet(loadField(notNil, hmap, h, hmapCount))
+	})
+}
+
+// mapIterInitFunc returns runtime.mapiterinit, which starts the range loop
+// over the map m whose state is it.
+func (v *Visitor) mapIterInitFunc() *ir.Func {
+	m := ir.NewParam("m", bytePtrType)
+	it := ir.NewParam("it", types.NewPointer(v.hiter()))
+
+	return v.mapFunc("runtime.mapiterinit", types.Void, []*ir.Param{m, it}, func(fn *ir.Func) {
+		hmap, hiter := v.hmap(), v.hiter()
+
+		entry := newFuncBlock(fn, "")
+		isNil := newFuncBlock(fn, "-nil")
+		notNil := newFuncBlock(fn, "-not-nil")
+		entry.NewStore(i64(0), field(entry, hiter, it, hiterIndex))
+		entry.NewCondBr(entry.NewICmp(enum.IPredEQ, m, constant.NewNull(bytePtrType)), isNil, notNil)
+
+		isNil.NewStore(constant.NewNull(bytePtrType), field(isNil, hiter, it, hiterSlots))
+		isNil.NewStore(i64(0), field(isNil, hiter, it, hiterCap))
+		isNil.NewRet(nil)
+
+		h := notNil.NewBitCast(m, types.NewPointer(hmap))
+		notNil.NewStore(loadField(notNil, hmap, h, hmapSlots), field(notNil, hiter, it, hiterSlots))
+		notNil.NewStore(loadField(notNil, hmap, h, hmapCap), field(notNil, hiter, it, hiterCap))
+		notNil.NewRet(nil)
+	})
+}
+
+// mapIterNextFunc returns runtime.mapiternext, which moves the range loop
+// over the map m whose state is it to the next entry. It returns false at
+// the end of the loop.
+//
+// The loop goes through the slots the map had when it started. When the
+// table was rebuilt since, the entries are looked up in the new one, so
+// that the deleted ones are skipped and the values are the current ones.
+func (v *Visitor) mapIterNextFunc() *ir.Func {
+	m := ir.NewParam("m", bytePtrType)
+	it := ir.NewParam("it", types.NewPointer(v.hiter()))
+
+	return v.mapFunc("runtime.mapiternext", types.I1, []*ir.Param{m, it}, func(fn *ir.Func) {
+		hmap, hiter := v.hmap(), v.hiter()
+
+		entry := newFuncBlock(fn, "")
+		loop := newFuncBlock(fn, "-cond")
+		body := newFuncBlock(fn, "-body")
+		full := newFuncBlock(fn, "-full")
+		same := newFuncBlock(fn, "-same")
+		lookup := newFuncBlock(fn, "-lookup")
+		found := newFuncBlock(fn, "-found")
+		end := newFuncBlock(fn, "-end")
+
+		h := entry.NewBitCast(m, types.NewPointer(hmap))
+		slots := loadField(entry, hiter, it, hiterSlots)
+		capacity := loadField(entry, hiter, it, hiterCap)
+		indexPtr := field(entry, hiter, it, hiterIndex)
+		entry.NewBr(loop)
+
+		i := loop.NewLoad(types.I64, indexPtr)
+		loop.NewCondBr(loop.NewICmp(enum.IPredSLT, i, capacity), body, end)
+		end.NewRet(constant.False)
+
+		body.NewStore(body.NewAdd(i, i64(1)), indexPtr)
+		slot := body.NewGetElementPtr(types.I8, slots, body.NewMul(i, loadField(body, hmap, h, hmapSlotSize)))
+		body.NewCondBr(isFull(body, slot), full, loop)
+
+		key := full.NewGetElementPtr(types.I8, slot, i64(slotKeyOff))
+		rebuilt := full.NewICmp(enum.IPredNE, loadField(full, hmap, h, hmapSlots), slots)
+		full.NewCondBr(rebuilt, lookup, same)
+
+		ret := func(block *ir.Block, val value.Value) {
+			block.NewStore(key, field(block, hiter, it, hiterKey))
+			block.NewStore(val, field(block, hiter, it, hiterVal))
+			block.NewRet(constant.True)
+		}
+
+		ret(same, same.NewGetElementPtr(types.I8, slot, loadField(same, hmap, h, hmapValOff)))
+
+		val := lookup.NewCall(v.mapAccessFunc(), m, key)
+		lookup.NewCondBr(lookup.NewICmp(enum.IPredEQ, val, constant.NewNull(bytePtrType)), loop, found)
+		ret(found, val)
+	})
+}
+
+// memhashFunc returns runtime.memhash, which returns the FNV-1a hash of the
+// n bytes at p.
+func (v *Visitor) memhashFunc() *ir.Func {
+	p := ir.NewParam("p", bytePtrType)
+	n := ir.NewParam("n", types.I64)
+
+	return v.mapFunc("runtime.memhash", types.I64, []*ir.Param{p, n}, func(fn *ir.Func) {
+		entry := newFuncBlock(fn, "")
+		loop := newFuncBlock(fn, "-cond")
+		body := newFuncBlock(fn, "-body")
+		done := newFuncBlock(fn, "-done")
+		entry.NewBr(loop)
+
+		i := loop.NewPhi(ir.NewIncoming(i64(0), entry))
+		hash := loop.NewPhi(ir.NewIncoming(i64(fnvOffset), entry))
+		loop.NewCondBr(loop.NewICmp(enum.IPredSLT, i, n), body, done)
+
+		b := body.NewZExt(body.NewLoad(types.I8, body.NewGetElementPtr(types.I8, p, i)), types.I64)
+		nextHash := body.NewMul(body.NewXor(hash, b), i64(fnvPrime))
+		nextIndex := body.NewAdd(i, i64(1))
+		body.NewBr(loop)
+		i.Incs = append(i.Incs, ir.NewIncoming(nextIndex, body))
+		hash.Incs = append(hash.Incs, ir.NewIncoming(nextHash, body))
+
+		done.NewRet(hash)
+	})
+}
+
+// hashFunc returns the hash function of the keys of type t, equal keys have
+// the same hash.
+func (v *Visitor) hashFunc(t check.Type) *ir.Func {
+	p := ir.NewParam("p", bytePtrType)
+
+	return v.mapFunc("runtime.hash."+v.goType(t).Name(), types.I64, []*ir.Param{p}, func(fn *ir.Func) {
+		entry := newFuncBlock(fn, "")
+		typ := v.llvmType(t)
+		ptr := entry.NewBitCast(p, types.NewPointer(memoryType(typ)))
+
+		switch t := t.Underlying().(type) {
+		case *check.Array:
+			// The hashes of the elements are combined like the bytes of
+			// memhash.
+			loop := newFuncBlock(fn, "-cond")
+			body := newFuncBlock(fn, "-body")
+			done := newFuncBlock(fn, "-done")
+			entry.NewBr(loop)
+
+			i := loop.NewPhi(ir.NewIncoming(i64(0), entry))
+			hash := loop.NewPhi(ir.NewIncoming(i64(fnvOffset), entry))
+			loop.NewCondBr(loop.NewICmp(enum.IPredSLT, i, i64(t.Len())), body, done)
+
+			elem := body.NewBitCast(body.NewGetElementPtr(typ, ptr, i64(0), i), bytePtrType)
+			elemHash := body.NewCall(v.hashFunc(t.Elem()), elem)
+			nextHash := body.NewMul(body.NewXor(hash, elemHash), i64(fnvPrime))
+			nextIndex := body.NewAdd(i, i64(1))
+			body.NewBr(loop)
+			i.Incs = append(i.Incs, ir.NewIncoming(nextIndex, body))
+			hash.Incs = append(hash.Incs, ir.NewIncoming(nextHash, body))
+
+			done.NewRet(hash)
+			return
+
+		case *check.Struct:
+			// The hashes of the fields are combined the same way, blank
+			// fields are skipped.
+			var hash value.Value = i64(fnvOffset)
+			for i := 0; i < t.NumFields(); i++ {
+				if f := t.Field(i); f.Name() != "_" {
+					fieldPtr := entry.NewBitCast(field(entry, typ.(*types.StructType), ptr, int64(i)), bytePtrType)
+					fieldHash := entry.NewCall(v.hashFunc(f.Type()), fieldPtr)
+					hash = entry.NewMul(entry.NewXor(hash, fieldHash), i64(fnvPrime))
+				}
+			}
+			entry.NewRet(hash)
+			return
+		}
+
+		size := i64(v.goType(t).Size())
+		switch {
+		case isStringType(t):
+			str := entry.NewLoad(typ, ptr)
+			entry.NewRet(entry.NewCall(v.memhashFunc(), entry.NewExtractValue(str, 1), entry.NewExtractValue(str, 0)))
+
+		case v.isFloat(t):
+			// -0 and +0 are equal, they are hashed as +0.
+			zero := constant.NewFloat(typ.(*types.FloatType), 0)
+			x := entry.NewLoad(typ, ptr)
+			tmp := entry.NewAlloca(typ)
+			entry.NewStore(entry.NewSelect(entry.NewFCmp(enum.FPredOEQ, x, zero), zero, x), tmp)
+			entry.NewRet(entry.NewCall(v.memhashFunc(), entry.NewBitCast(tmp, bytePtrType), size))
+
+		default:
+			entry.NewRet(entry.NewCall(v.memhashFunc(), p, size))
+		}
+	})
+}
+
+// equalFunc returns the equality function of the keys of type t.
+func (v *Visitor) equalFunc(t check.Type) *ir.Func {
+	x := ir.NewParam("x", bytePtrType)
+	y := ir.NewParam("y", bytePtrType)
+
+	return v.mapFunc("runtime.equal."+v.goType(t).Name(), types.I1, []*ir.Param{x, y}, func(fn *ir.Func) {
+		entry := newFuncBlock(fn, "")
+		typ := v.llvmType(t)
+		ptrType := types.NewPointer(memoryType(typ))
+		xPtr := entry.NewBitCast(x, ptrType)
+		yPtr := entry.NewBitCast(y, ptrType)
+
+		switch t := t.Underlying().(type) {
+		case *check.Array:
+			// The loop stops at the first elements that differ.
+			loop := newFuncBlock(fn, "-cond")
+			body := newFuncBlock(fn, "-body")
+			equal := newFuncBlock(fn, "-equal")
+			differ := newFuncBlock(fn, "-differ")
+			entry.NewBr(loop)
+
+			i := loop.NewPhi(ir.NewIncoming(i64(0), entry))
+			loop.NewCondBr(loop.NewICmp(enum.IPredSLT, i, i64(t.Len())), body, equal)
+
+			xElem := body.NewBitCast(body.NewGetElementPtr(typ, xPtr, i64(0), i), bytePtrType)
+			yElem := body.NewBitCast(body.NewGetElementPtr(typ, yPtr, i64(0), i), bytePtrType)
+			eq := body.NewCall(v.equalFunc(t.Elem()), xElem, yElem)
+			nextIndex := body.NewAdd(i, i64(1))
+			body.NewCondBr(eq, loop, differ)
+			i.Incs = append(i.Incs, ir.NewIncoming(nextIndex, body))
+
+			equal.NewRet(constant.True)
+			differ.NewRet(constant.False)
+			return
+
+		case *check.Struct:
+			// The fields are compared in order until two differ, blank
+			// fields are skipped.
+			differ := newFuncBlock(fn, "-differ")
+			differ.NewRet(constant.False)
+
+			block := entry
+			for i := 0; i < t.NumFields(); i++ {
+				f := t.Field(i)
+				if f.Name() == "_" {
+					continue
+				}
+				xField := block.NewBitCast(field(block, typ.(*types.StructType), xPtr, int64(i)), bytePtrType)
+				yField := block.NewBitCast(field(block, typ.(*types.StructType), yPtr, int64(i)), bytePtrType)
+				next := newFuncBlock(fn, "-next")
+				block.NewCondBr(block.NewCall(v.equalFunc(f.Type()), xField, yField), next, differ)
+				block = next
+			}
+			block.NewRet(constant.True)
+			return
+		}
+
+		xVal := entry.NewLoad(memoryType(typ), xPtr)
+		yVal := entry.NewLoad(memoryType(typ), yPtr)
+		switch {
+		case isStringType(t):
+			// The bytes are only compared when the lengths are equal.
+			sameLen := newFuncBlock(fn, "-same-len")
+			differ := newFuncBlock(fn, "-differ")
+			n := entry.NewExtractValue(xVal, 0)
+			entry.NewCondBr(entry.NewICmp(enum.IPredEQ, n, entry.NewExtractValue(yVal, 0)), sameLen, differ)
+			differ.NewRet(constant.False)
+
+			cmp := sameLen.NewCall(v.memcmpFunc(), sameLen.NewExtractValue(xVal, 1), sameLen.NewExtractValue(yVal, 1), n)
+			sameLen.NewRet(sameLen.NewICmp(enum.IPredEQ, cmp, constant.NewInt(types.I32, 0)))
+
+		case v.isFloat(t):
+			entry.NewRet(entry.NewFCmp(enum.FPredOEQ, xVal, yVal))
+
+		default:
+			entry.NewRet(entry.NewICmp(enum.IPredEQ, xVal, yVal))
+		}
+	})
+}
+
+// keyFuncs returns the hash and equality functions of the keys of maps of
+// type t.
+func (v *Visitor) keyFuncs(t *check.Map) (hash, equal *ir.Func) {
+	return v.hashFunc(t.Key()), v.equalFunc(t.Key())
+}
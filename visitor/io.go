@@ -1,11 +1,45 @@
 package visitor
 
 import (
+	"github.com/llir/llvm/ir/types"
 	"github.com/llir/llvm/ir/value"
+
+	"nano-go/ast"
 	"nano-go/visitor/syscall"
 )
 
-func (v *Visitor) printFuncCall(arguments []value.Value) value.Value {
-	syscall.Print(v.curBlock, arguments[0], "linux")
-	return nil
+// visitPrintCall lowers a call of the builtin Print, which writes its string
+// with a system call, or Printf, which calls the printf of C.
+func (v *Visitor) visitPrintCall(call *ast.CallExpr, builtin string, args []value.Value) value.Value {
+	if builtin == "Print" {
+		syscall.Print(v.curBlock, args[0], v.GOOS)
+		return nil
+	}
+
+	args[0] = v.curBlock.NewExtractValue(args[0], 1)
+	v.promoteVariadicArgs(call, args)
+	return v.curBlock.NewCall(v.printfFn, args...)
+}
+
+// promoteVariadicArgs extends the integers narrower than int and the
+// float32 values passed to the variadic arguments of a C function to int
+// and double, as C does.
+func (v *Visitor) promoteVariadicArgs(call *ast.CallExpr, args []value.Value) {
+	for i := 1; i < len(args); i++ {
+		switch typ := args[i].Type().(type) {
+		case *types.IntType:
+			if typ.BitSize >= 32 {
+				continue
+			}
+			if v.isUnsigned(v.typeOf(call.Args[i])) {
+				args[i] = v.curBlock.NewZExt(args[i], types.I32)
+			} else {
+				args[i] = v.curBlock.NewSExt(args[i], types.I32)
+			}
+		case *types.FloatType:
+			if typ.Kind == types.FloatKindFloat {
+				args[i] = v.curBlock.NewFPExt(args[i], types.Double)
+			}
+		}
+	}
 }
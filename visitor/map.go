@@ -0,0 +1,166 @@
+package visitor
+
+import (
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/ast"
+	"nano-go/check"
+	"nano-go/visitor/name"
+)
+
+// newMap makes a map of type t with room for hint entries.
+func (v *Visitor) newMap(t *check.Map, hint value.Value) value.Value {
+	hash, equal := v.keyFuncs(t)
+	keySize := i64(v.goType(t.Key()).Size())
+	valSize := i64(v.goType(t.Elem()).Size())
+	return v.curBlock.NewCall(v.makeMapFunc(), keySize, valSize, hash, equal, hint)
+}
+
+// visitMakeMap lowers make(map[K]V, hint). A negative hint is ignored like
+// Go does.
+func (v *Visitor) visitMakeMap(call *ast.CallExpr, t *check.Map) value.Value {
+	var hint value.Value = i64(0)
+	if len(call.Args) == 2 {
+		hint = v.toInt64(v.visitExpression(call.Args[1]), v.typeOf(call.Args[1]))
+	}
+	return v.newMap(t, hint)
+}
+
+// visitMapLit lowers a map literal, the entries are added in order.
+func (v *Visitor) visitMapLit(lit *ast.CompositeLit, t *check.Map) value.Value {
+	m := v.newMap(t, i64(int64(len(lit.Elts))))
+
+	for _, elt := range lit.Elts {
+		kv := elt.(*ast.KeyValueExpr)
+		key := v.visitExpression(kv.Key)
+		val := v.visitExpression(kv.Value)
+		v.store(val, v.mapAssign(m, t, key))
+	}
+
+	return m
+}
+
+// keyAddr stores the key val in a temporary variable and returns its
+// address, the runtime takes keys by address.
+func (v *Visitor) keyAddr(val value.Value) value.Value {
+	tmp := v.alloca(val.Type(), "")
+	v.store(val, tmp)
+	return v.bytePtr(tmp)
+}
+
+// mapAssign returns the address of the value of key in the map m of type t,
+// which is not nil. The entry is added when it is missing.
+func (v *Visitor) mapAssign(m value.Value, t *check.Map, key value.Value) value.Value {
+	ptr := v.curBlock.NewCall(v.mapAssignFunc(), m, v.keyAddr(key))
+	return v.curBlock.NewBitCast(ptr, types.NewPointer(memoryType(v.llvmType(t.Elem()))))
+}
+
+// mapElemAddr returns the address of the map element expr is assigned to.
+func (v *Visitor) mapElemAddr(expr *ast.IndexExpr, t *check.Map) value.Value {
+	return v.mapElemTarget(expr, t)()
+}
+
+// mapElemTarget evaluates the map and the key of the map element expr is
+// assigned to and returns a function giving its address. The entry is only
+// added when its address is asked for: adding entries may grow the map and
+// move the values, so the other operands of the assignment must be evaluated
+// before. Assigning to an element of the nil map panics.
+func (v *Visitor) mapElemTarget(expr *ast.IndexExpr, t *check.Map) func() value.Value {
+	m := v.visitExpression(expr.X)
+	key := v.visitExpression(expr.Index)
+
+	return func() value.Value {
+		panicBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-panic")
+		okBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-ok")
+		v.curBlock.NewCondBr(v.curBlock.NewICmp(enum.IPredEQ, m, constant.NewNull(bytePtrType)), panicBlock, okBlock)
+
+		v.curBlock = panicBlock
+		v.panicMsg(expr, "assignment to entry in nil map")
+
+		v.curBlock = okBlock
+		return v.mapAssign(m, t, key)
+	}
+}
+
+// visitMapIndex lowers the lookup of a key in a map. A missing key gives the
+// zero value, the comma-ok form gives whether the key is present too.
+func (v *Visitor) visitMapIndex(expr *ast.IndexExpr, t *check.Map) value.Value {
+	m := v.visitExpression(expr.X)
+	key := v.visitExpression(expr.Index)
+	ptr := v.curBlock.NewCall(v.mapAccessFunc(), m, v.keyAddr(key))
+
+	elemType := v.goType(t.Elem())
+	res := v.alloca(elemType.LLVM(), "")
+	elemType.Zero(v.curBlock, res)
+
+	foundBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-found")
+	afterBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-after")
+	found := v.curBlock.NewICmp(enum.IPredNE, ptr, constant.NewNull(bytePtrType))
+	v.curBlock.NewCondBr(found, foundBlock, afterBlock)
+
+	v.curBlock = foundBlock
+	elemPtr := v.curBlock.NewBitCast(ptr, types.NewPointer(memoryType(elemType.LLVM())))
+	v.store(v.load(elemType.LLVM(), elemPtr), res)
+	v.curBlock.NewBr(afterBlock)
+
+	v.curBlock = afterBlock
+	val := v.load(elemType.LLVM(), res)
+	if _, ok := v.typeOf(expr).(*check.Tuple); !ok {
+		return val
+	}
+
+	var tuple value.Value = constant.NewUndef(types.NewStruct(val.Type(), types.I1))
+	tuple = v.curBlock.NewInsertValue(tuple, val, 0)
+	return v.curBlock.NewInsertValue(tuple, found, 1)
+}
+
+// visitDeleteCall lowers delete(m, key).
+func (v *Visitor) visitDeleteCall(call *ast.CallExpr) value.Value {
+	m := v.visitExpression(call.Args[0])
+	key := v.visitExpression(call.Args[1])
+	return v.curBlock.NewCall(v.mapDeleteFunc(), m, v.keyAddr(key))
+}
+
+// visitMapRange lowers a range loop over the map m of type t. The runtime
+// gives the addresses of the key and the value of every entry.
+func (v *Visitor) visitMapRange(stmt *ast.RangeStmt, m value.Value, t *check.Map) {
+	hiter := v.hiter()
+	it := v.alloca(hiter, "")
+	v.curBlock.NewCall(v.mapIterInitFunc(), m, it)
+
+	condBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-cond")
+	bodyBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-body")
+	afterBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-after-for")
+
+	v.curBlock.NewBr(condBlock)
+
+	// cond
+	v.curBlock = condBlock
+	v.curBlock.NewCondBr(v.curBlock.NewCall(v.mapIterNextFunc(), m, it), bodyBlock, afterBlock)
+
+	// body
+	v.curBlock = bodyBlock
+	entry := func(i int64, t check.Type) value.Value {
+		typ := v.llvmType(t)
+		ptr := v.curBlock.NewBitCast(loadField(v.curBlock, hiter, it, i), types.NewPointer(memoryType(typ)))
+		return v.load(typ, ptr)
+	}
+	if stmt.Key != nil && !ast.IsBlank(stmt.Key) {
+		v.rangeAssign(stmt, stmt.Key, entry(hiterKey, t.Key()))
+	}
+	if stmt.Value != nil && !ast.IsBlank(stmt.Value) {
+		v.rangeAssign(stmt, stmt.Value, entry(hiterVal, t.Elem()))
+	}
+
+	v.pushBranchTarget(afterBlock, condBlock)
+	v.visitBlock(stmt.Body)
+	v.popBranchTarget()
+	if v.curBlock.Term == nil {
+		v.curBlock.NewBr(condBlock)
+	}
+
+	v.curBlock = afterBlock
+}
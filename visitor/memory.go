@@ -0,0 +1,65 @@
+package visitor
+
+import (
+	"fmt"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// memoryType returns the type values of typ have in memory. Booleans are
+// i1 in registers and take a byte in memory.
+func memoryType(typ types.Type) types.Type {
+	if types.Equal(typ, types.I1) {
+		return types.I8
+	}
+	return typ
+}
+
+// alloca allocates a variable for values of type typ. Variables shadowing
+// one with the same name get numbered names. The variables are allocated in
+// the entry block, so that the ones declared in loops do not grow the stack.
+func (v *Visitor) alloca(typ types.Type, name string) *ir.InstAlloca {
+	entry := v.curBlock.Parent.Blocks[0]
+	alloca := ir.NewAlloca(memoryType(typ))
+	entry.Insts = append([]ir.Instruction{alloca}, entry.Insts...)
+	if name == "" {
+		return alloca
+	}
+
+	if n := v.localNames[name]; n > 0 {
+		alloca.SetName(fmt.Sprintf("%s.%d", name, n))
+	} else {
+		alloca.SetName(name)
+	}
+	v.localNames[name]++
+	return alloca
+}
+
+// toMemory converts val to the type it has in memory, the type of the
+// elements of arrays.
+func (v *Visitor) toMemory(val value.Value) value.Value {
+	if typ := memoryType(val.Type()); typ != val.Type() {
+		return v.curBlock.NewZExt(val, typ)
+	}
+	return val
+}
+
+// fromMemory converts val, read from memory, to a value of type typ.
+func (v *Visitor) fromMemory(typ types.Type, val value.Value) value.Value {
+	if memoryType(typ) != typ {
+		return v.curBlock.NewTrunc(val, typ)
+	}
+	return val
+}
+
+// load loads a value of type typ from the variable ptr.
+func (v *Visitor) load(typ types.Type, ptr value.Value) value.Value {
+	return v.fromMemory(typ, v.curBlock.NewLoad(memoryType(typ), ptr))
+}
+
+// store stores val to the variable ptr.
+func (v *Visitor) store(val value.Value, ptr value.Value) {
+	v.curBlock.NewStore(v.toMemory(val), ptr)
+}
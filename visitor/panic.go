@@ -0,0 +1,231 @@
+package visitor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/ast"
+	"nano-go/visitor/name"
+	irstrings "nano-go/visitor/strings"
+	"nano-go/visitor/syscall"
+	"nano-go/visitor/type"
+)
+
+// panicExitCode is the status of a program stopped by a panic.
+const panicExitCode = 2
+
+// panicIf emits a check that stops the program with the runtime error msg
+// when cond holds, the code after it runs otherwise.
+func (v *Visitor) panicIf(cond value.Value, node ast.Node, msg string) {
+	panicBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-panic")
+	okBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-ok")
+	v.curBlock.NewCondBr(cond, panicBlock, okBlock)
+
+	v.curBlock = panicBlock
+	v.runtimeError(node, msg)
+
+	v.curBlock = okBlock
+}
+
+// runtimeError stops the program with the runtime error msg.
+func (v *Visitor) runtimeError(node ast.Node, msg string) {
+	v.panicMsg(node, "runtime error: "+msg)
+}
+
+// panicMsg stops the program with a panic of msg. The report is the one of
+// Go, the trace only has the function node is in.
+func (v *Visitor) panicMsg(node ast.Node, msg string) {
+	v.curBlock.NewCall(v.panicFunc(), v.stringConstant(v.panicReport(node, msg)))
+	v.curBlock.NewUnreachable()
+}
+
+// panicReport returns the report of the panic of msg at node.
+func (v *Visitor) panicReport(node ast.Node, msg string) string {
+	pos := node.Pos()
+	// The symbols of the functions are qualified, except the one of main.
+	fn := v.curBlock.Parent.Name()
+	if fn == "main" {
+		fn = pkgSymbol(fn)
+	}
+	return fmt.Sprintf("panic: %s\n\ngoroutine 1 [running]:\n%s()\n\t%s:%d\n",
+		msg, fn, pos.Filename, pos.Line)
+}
+
+// boundsKind is the kind of an index or slice bounds error, they are the
+// ones of the Go runtime.
+type boundsKind int
+
+const (
+	boundsIndex      boundsKind = iota // s[x], 0 <= x < y failed
+	boundsSliceAlen                    // s[?:x], 0 <= x <= y failed, y is len(s)
+	boundsSliceAcap                    // s[?:x], 0 <= x <= y failed, y is cap(s)
+	boundsSliceB                       // s[x:y], 0 <= x <= y failed
+	boundsSlice3Alen                   // s[?:?:x], 0 <= x <= y failed, y is len(s)
+	boundsSlice3Acap                   // s[?:?:x], 0 <= x <= y failed, y is cap(s)
+	boundsSlice3B                      // s[?:x:y], 0 <= x <= y failed
+	boundsSlice3C                      // s[x:y:?], 0 <= x <= y failed
+)
+
+// boundsErrorFmt are the reports of the bounds errors, %x is the bound out
+// of range and %y the value it was compared with.
+var boundsErrorFmt = [...]string{
+	boundsIndex:      "index out of range [%x] with length %y",
+	boundsSliceAlen:  "slice bounds out of range [:%x] with length %y",
+	boundsSliceAcap:  "slice bounds out of range [:%x] with capacity %y",
+	boundsSliceB:     "slice bounds out of range [%x:%y]",
+	boundsSlice3Alen: "slice bounds out of range [::%x] with length %y",
+	boundsSlice3Acap: "slice bounds out of range [::%x] with capacity %y",
+	boundsSlice3B:    "slice bounds out of range [:%x:%y]",
+	boundsSlice3C:    "slice bounds out of range [%x:%y:]",
+}
+
+// boundsNegErrorFmt are the reports of the bounds errors of a negative x,
+// y is left out.
+var boundsNegErrorFmt = [...]string{
+	boundsIndex:      "index out of range [%x]",
+	boundsSliceAlen:  "slice bounds out of range [:%x]",
+	boundsSliceAcap:  "slice bounds out of range [:%x]",
+	boundsSliceB:     "slice bounds out of range [%x:]",
+	boundsSlice3Alen: "slice bounds out of range [::%x]",
+	boundsSlice3Acap: "slice bounds out of range [::%x]",
+	boundsSlice3B:    "slice bounds out of range [:%x:]",
+	boundsSlice3C:    "slice bounds out of range [%x::]",
+}
+
+// boundsCheck emits a check that stops the program with a bounds error of
+// the given kind when x is out of range of y. Both are i64, x is compared
+// as unsigned so that a negative bound is out of range too, signed tells
+// whether it is reported as a signed value. Constant bounds were checked by
+// the checker.
+func (v *Visitor) boundsCheck(node ast.Node, kind boundsKind, x, y value.Value, signed bool) {
+	_, constX := x.(*constant.Int)
+	_, constY := y.(*constant.Int)
+	if constX && constY {
+		return
+	}
+
+	pred := enum.IPredUGT
+	if kind == boundsIndex {
+		pred = enum.IPredUGE
+	}
+
+	panicBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-panic")
+	okBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-ok")
+	v.curBlock.NewCondBr(v.curBlock.NewICmp(pred, x, y), panicBlock, okBlock)
+
+	v.curBlock = panicBlock
+	verbs := strings.NewReplacer("%x", "%lld", "%y", "%lld")
+	if signed {
+		negBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-panic")
+		posBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-panic")
+		v.curBlock.NewCondBr(v.curBlock.NewICmp(enum.IPredSLT, x, constant.NewInt(types.I64, 0)), negBlock, posBlock)
+
+		v.curBlock = negBlock
+		v.runtimeErrorf(node, verbs.Replace(boundsNegErrorFmt[kind]), x, y)
+		v.curBlock = posBlock
+	} else {
+		verbs = strings.NewReplacer("%x", "%llu", "%y", "%lld")
+	}
+	v.runtimeErrorf(node, verbs.Replace(boundsErrorFmt[kind]), x, y)
+
+	v.curBlock = okBlock
+}
+
+// panicReportSize is the size of the buffer the reports with values are
+// formatted in, longer reports are cut.
+const panicReportSize = 512
+
+// runtimeErrorf stops the program with the runtime error format, whose
+// verbs are the ones of printf, formatted with the i64 values x and y at
+// run time. The format may use only x.
+func (v *Visitor) runtimeErrorf(node ast.Node, format string, x, y value.Value) {
+	// The position and the function name are not verbs.
+	report := strings.ReplaceAll(v.panicReport(node, "runtime error: \x00"), "%", "%%")
+	report = strings.Replace(report, "\x00", format, 1)
+
+	global := v.Module.NewGlobalDef(irstrings.NextStringName(), irstrings.Constant(report))
+	global.Immutable = true
+
+	v.curBlock.NewCall(v.panicfFunc(), irstrings.Toi8Ptr(v.curBlock, global), x, y)
+	v.curBlock.NewUnreachable()
+}
+
+// panicfFunc returns the runtime function formatting a panic report with
+// two values and panicking with it, it is generated by the first one. The
+// report is formatted in a global buffer, the program stops after it.
+func (v *Visitor) panicfFunc() *ir.Func {
+	if v.panicfFn != nil {
+		return v.panicfFn
+	}
+
+	format := ir.NewParam("format", types.NewPointer(types.I8))
+	x := ir.NewParam("x", types.I64)
+	y := ir.NewParam("y", types.I64)
+	v.panicfFn = v.Module.NewFunc("runtime.panicf", types.Void, format, x, y)
+	v.panicfFn.FuncAttrs = append(v.panicfFn.FuncAttrs, enum.FuncAttrNoReturn)
+
+	bufType := types.NewArray(panicReportSize, types.I8)
+	buf := v.Module.NewGlobalDef("runtime.panicbuf", constant.NewZeroInitializer(bufType))
+
+	block := v.panicfFn.NewBlock(name.BlockName())
+	size := constant.NewInt(types.I64, panicReportSize)
+	data := block.NewGetElementPtr(bufType, buf, constant.NewInt(types.I64, 0), constant.NewInt(types.I64, 0))
+	var n value.Value = block.NewSExt(block.NewCall(v.snprintfFunc(), data, size, format, x, y), types.I64)
+
+	// snprintf returns the length of the whole report, the buffer has it
+	// up to the terminating zero.
+	cut := block.NewICmp(enum.IPredSGE, n, size)
+	n = block.NewSelect(cut, constant.NewInt(types.I64, panicReportSize-1), n)
+
+	var str value.Value = constant.NewUndef(_type.String.LLVM())
+	str = block.NewInsertValue(str, n, 0)
+	str = block.NewInsertValue(str, data, 1)
+
+	block.NewCall(v.panicFunc(), str)
+	block.NewUnreachable()
+
+	return v.panicfFn
+}
+
+// snprintfFunc returns the C function formatting the reports of runtime
+// errors, it is declared by the first one.
+func (v *Visitor) snprintfFunc() *ir.Func {
+	if v.snprintfFn == nil {
+		v.snprintfFn = v.Module.NewFunc("snprintf", types.I32,
+			ir.NewParam("buf", types.NewPointer(types.I8)),
+			ir.NewParam("size", types.I64),
+			ir.NewParam("format", types.NewPointer(types.I8)),
+		)
+		v.snprintfFn.Sig.Variadic = true
+	}
+	return v.snprintfFn
+}
+
+// panicFunc returns the runtime function printing a panic report to stderr
+// and exiting, it is generated by the first panic.
+func (v *Visitor) panicFunc() *ir.Func {
+	if v.panicFn != nil {
+		return v.panicFn
+	}
+
+	// The output buffered by printf is flushed before the report.
+	fflush := v.Module.NewFunc("fflush", types.I32, ir.NewParam("stream", types.NewPointer(types.I8)))
+
+	report := ir.NewParam("report", _type.String.LLVM())
+	v.panicFn = v.Module.NewFunc("runtime.panic", types.Void, report)
+	v.panicFn.FuncAttrs = append(v.panicFn.FuncAttrs, enum.FuncAttrNoReturn)
+
+	block := v.panicFn.NewBlock(name.BlockName())
+	block.NewCall(fflush, constant.NewNull(types.NewPointer(types.I8)))
+	syscall.Write(block, 2, report, v.GOOS)
+	syscall.Exit(block, constant.NewInt(types.I64, panicExitCode), v.GOOS)
+	block.NewUnreachable()
+
+	return v.panicFn
+}
@@ -0,0 +1,286 @@
+package visitor
+
+import (
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/ast"
+	"nano-go/check"
+	"nano-go/visitor/name"
+	"nano-go/visitor/pointer"
+	"nano-go/visitor/type"
+)
+
+// newSlice builds the slice of the elements at data.
+func (v *Visitor) newSlice(t *_type.SliceType, data, length, capacity value.Value) value.Value {
+	var slice value.Value = constant.NewUndef(t.LLVM())
+	slice = v.curBlock.NewInsertValue(slice, data, 0)
+	slice = v.curBlock.NewInsertValue(slice, length, 1)
+	return v.curBlock.NewInsertValue(slice, capacity, 2)
+}
+
+// visitSliceLit lowers a slice literal, the elements are stored in a new
+// backing array of the given length at their indices.
+func (v *Visitor) visitSliceLit(lit *ast.CompositeLit, t *_type.SliceType, indices []int64, length int64) value.Value {
+	n := constant.NewInt(types.I64, length)
+	data := v.newArray(t.Elem, n)
+
+	for i, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			elt = kv.Value
+		}
+		ptr := v.curBlock.NewGetElementPtr(pointer.ElemType(data), data, constant.NewInt(types.I64, indices[i]))
+		v.store(v.visitExpression(elt), ptr)
+	}
+
+	return v.newSlice(t, data, n, n)
+}
+
+// visitMakeCall lowers make([]T, len, cap) and make(map[K]V, hint). The
+// elements of slices are zeroed by the allocation.
+func (v *Visitor) visitMakeCall(call *ast.CallExpr) value.Value {
+	if t, ok := v.typeOf(call).Underlying().(*check.Map); ok {
+		return v.visitMakeMap(call, t)
+	}
+
+	t := v.goType(v.typeOf(call)).(*_type.SliceType)
+
+	length := v.toInt64(v.visitExpression(call.Args[1]), v.typeOf(call.Args[1]))
+	capacity := length
+	if len(call.Args) == 3 {
+		capacity = v.toInt64(v.visitExpression(call.Args[2]), v.typeOf(call.Args[2]))
+	}
+
+	// Constant sizes were checked by the checker.
+	if !isConstant(length) {
+		isNegative := v.curBlock.NewICmp(enum.IPredSLT, length, constant.NewInt(types.I64, 0))
+		v.panicIf(isNegative, call, "makeslice: len out of range")
+	}
+	if !isConstant(length) || !isConstant(capacity) {
+		v.panicIf(v.curBlock.NewICmp(enum.IPredSGT, length, capacity), call, "makeslice: cap out of range")
+	}
+
+	return v.newSlice(t, v.newArray(t.Elem, capacity), length, capacity)
+}
+
+// isConstant reports whether val is an integer constant.
+func isConstant(val value.Value) bool {
+	_, ok := val.(*constant.Int)
+	return ok
+}
+
+// visitSliceExpr lowers x[lo:hi:max] of a string, an addressable array or
+// a slice. The bounds are checked like Go does, the last one first.
+func (v *Visitor) visitSliceExpr(expr *ast.SliceExpr) value.Value {
+	var data, length, capacity value.Value
+	// The errors of strings and arrays report the length, the capacity of
+	// a slice otherwise.
+	reportLen := true
+
+	switch t := v.typeOf(expr.X).Underlying().(type) {
+	case *check.Basic:
+		str := v.visitExpression(expr.X)
+		length = v.curBlock.NewExtractValue(str, 0)
+		data = v.curBlock.NewExtractValue(str, 1)
+		capacity = length
+
+	case *check.Array:
+		arr := v.address(expr.X)
+		zero := constant.NewInt(types.I64, 0)
+		data = v.curBlock.NewGetElementPtr(v.llvmType(t), arr, zero, zero)
+		length = constant.NewInt(types.I64, t.Len())
+		capacity = length
+
+	case *check.Slice:
+		slice := v.visitExpression(expr.X)
+		data = v.curBlock.NewExtractValue(slice, 0)
+		length = v.curBlock.NewExtractValue(slice, 1)
+		capacity = v.curBlock.NewExtractValue(slice, 2)
+		reportLen = false
+
+	default:
+		v.fatalf(expr, "slicing of %s is not implemented yet", v.typeOf(expr.X))
+	}
+
+	// bound lowers an index, def when it is missing.
+	bound := func(e ast.Expr, def value.Value) value.Value {
+		if e == nil {
+			return def
+		}
+		return v.toInt64(v.visitExpression(e), v.typeOf(e))
+	}
+	signed := func(e ast.Expr) bool {
+		return e != nil && !v.isUnsigned(v.typeOf(e))
+	}
+
+	lo := bound(expr.Low, constant.NewInt(types.I64, 0))
+	hi := bound(expr.High, length)
+	max := bound(expr.Max, capacity)
+
+	if expr.Slice3 {
+		kind := boundsSlice3Acap
+		if reportLen {
+			kind = boundsSlice3Alen
+		}
+		v.boundsCheck(expr, kind, max, capacity, signed(expr.Max))
+		v.boundsCheck(expr, boundsSlice3B, hi, max, signed(expr.High))
+		v.boundsCheck(expr, boundsSlice3C, lo, hi, signed(expr.Low))
+	} else {
+		kind := boundsSliceAcap
+		if reportLen {
+			kind = boundsSliceAlen
+		}
+		if expr.High != nil {
+			v.boundsCheck(expr, kind, hi, capacity, signed(expr.High))
+		}
+		v.boundsCheck(expr, boundsSliceB, lo, hi, signed(expr.Low))
+	}
+
+	ptr := v.curBlock.NewGetElementPtr(pointer.ElemType(data), data, lo)
+	newLen := v.curBlock.NewSub(hi, lo)
+
+	switch t := v.goType(v.typeOf(expr)).(type) {
+	case *_type.SliceType:
+		return v.newSlice(t, ptr, newLen, v.curBlock.NewSub(max, lo))
+	default:
+		var str value.Value = constant.NewUndef(_type.String.LLVM())
+		str = v.curBlock.NewInsertValue(str, newLen, 0)
+		return v.curBlock.NewInsertValue(str, ptr, 1)
+	}
+}
+
+// visitAppendCall lowers append. The elements are stored after the ones of
+// the slice, in a new backing array when they do not fit in its capacity.
+func (v *Visitor) visitAppendCall(call *ast.CallExpr) value.Value {
+	t := v.goType(v.typeOf(call)).(*_type.SliceType)
+	slice := v.visitExpression(call.Args[0])
+
+	// The appended elements are values, or the elements of a slice or the
+	// bytes of a string passed with ....
+	var values []value.Value
+	var src, n value.Value
+	if call.Ellipsis {
+		src, n = v.sliceData(call.Args[1])
+	} else {
+		for _, arg := range call.Args[1:] {
+			values = append(values, v.visitExpression(arg))
+		}
+		n = constant.NewInt(types.I64, int64(len(values)))
+	}
+
+	length := v.curBlock.NewExtractValue(slice, 1)
+	newLen := v.curBlock.NewAdd(length, n)
+
+	size := constant.NewInt(types.I64, t.Elem.Size())
+	grown := v.curBlock.NewCall(v.growSliceFunc(),
+		v.bytePtr(v.curBlock.NewExtractValue(slice, 0)),
+		length,
+		v.curBlock.NewExtractValue(slice, 2),
+		newLen,
+		size,
+	)
+	data := v.curBlock.NewBitCast(v.curBlock.NewExtractValue(grown, 0), types.NewPointer(memoryType(t.Elem.LLVM())))
+	capacity := v.curBlock.NewExtractValue(grown, 1)
+
+	end := v.curBlock.NewGetElementPtr(pointer.ElemType(data), data, length)
+	if src != nil {
+		v.curBlock.NewCall(v.memmoveFunc(), v.bytePtr(end), v.bytePtr(src), v.curBlock.NewMul(n, size))
+	}
+	for i, val := range values {
+		ptr := v.curBlock.NewGetElementPtr(pointer.ElemType(data), end, constant.NewInt(types.I64, int64(i)))
+		v.store(val, ptr)
+	}
+
+	return v.newSlice(t, data, newLen, capacity)
+}
+
+// visitCopyCall lowers copy, it copies the elements both slices have.
+func (v *Visitor) visitCopyCall(call *ast.CallExpr) value.Value {
+	dst, dstLen := v.sliceData(call.Args[0])
+	src, srcLen := v.sliceData(call.Args[1])
+	elem := v.goType(v.typeOf(call.Args[0])).(*_type.SliceType).Elem
+
+	n := v.curBlock.NewSelect(v.curBlock.NewICmp(enum.IPredSLT, dstLen, srcLen), dstLen, srcLen)
+	size := v.curBlock.NewMul(n, constant.NewInt(types.I64, elem.Size()))
+	v.curBlock.NewCall(v.memmoveFunc(), v.bytePtr(dst), v.bytePtr(src), size)
+
+	return n
+}
+
+// sliceData returns the pointer to the elements of the slice expr and their
+// number. A string has bytes.
+func (v *Visitor) sliceData(expr ast.Expr) (data, length value.Value) {
+	val := v.visitExpression(expr)
+	if isStringType(v.typeOf(expr)) {
+		return v.curBlock.NewExtractValue(val, 1), v.curBlock.NewExtractValue(val, 0)
+	}
+	return v.curBlock.NewExtractValue(val, 0), v.curBlock.NewExtractValue(val, 1)
+}
+
+// bytePtr converts the pointer ptr to an i8*.
+func (v *Visitor) bytePtr(ptr value.Value) value.Value {
+	i8ptr := types.NewPointer(types.I8)
+	if types.Equal(ptr.Type(), i8ptr) {
+		return ptr
+	}
+	return v.curBlock.NewBitCast(ptr, i8ptr)
+}
+
+// memmoveFunc returns the C function copying memory, it is declared by the
+// first copy.
+func (v *Visitor) memmoveFunc() *ir.Func {
+	if v.memmoveFn == nil {
+		v.memmoveFn = v.Module.NewFunc("memmove", types.NewPointer(types.I8),
+			ir.NewParam("dst", types.NewPointer(types.I8)),
+			ir.NewParam("src", types.NewPointer(types.I8)),
+			ir.NewParam("n", types.I64),
+		)
+	}
+	return v.memmoveFn
+}
+
+// growSliceFunc returns the runtime function making room for newLen
+// elements of size bytes in the backing array data of a slice of length len
+// and capacity cap. It returns the backing array and its capacity, which
+// is doubled, or newLen when it is more. It is generated by the first
+// append.
+func (v *Visitor) growSliceFunc() *ir.Func {
+	if v.growSliceFn != nil {
+		return v.growSliceFn
+	}
+
+	i8ptr := types.NewPointer(types.I8)
+	data := ir.NewParam("data", i8ptr)
+	length := ir.NewParam("len", types.I64)
+	capacity := ir.NewParam("cap", types.I64)
+	newLen := ir.NewParam("newLen", types.I64)
+	size := ir.NewParam("size", types.I64)
+
+	result := types.NewStruct(i8ptr, types.I64)
+	v.growSliceFn = v.Module.NewFunc("runtime.growslice", result, data, length, capacity, newLen, size)
+	fn := v.growSliceFn
+
+	ret := func(block *ir.Block, data, capacity value.Value) {
+		var res value.Value = constant.NewUndef(result)
+		res = block.NewInsertValue(res, data, 0)
+		res = block.NewInsertValue(res, capacity, 1)
+		block.NewRet(res)
+	}
+
+	entry := fn.NewBlock(name.BlockName())
+	fits := fn.NewBlock(name.BlockName() + "-fits")
+	grow := fn.NewBlock(name.BlockName() + "-grow")
+	entry.NewCondBr(entry.NewICmp(enum.IPredSLE, newLen, capacity), fits, grow)
+	ret(fits, data, capacity)
+
+	doubled := grow.NewMul(capacity, constant.NewInt(types.I64, 2))
+	newCap := grow.NewSelect(grow.NewICmp(enum.IPredSLT, doubled, newLen), newLen, doubled)
+	mem := grow.NewCall(v.calloc(), newCap, size)
+	grow.NewCall(v.memmoveFunc(), mem, data, grow.NewMul(length, size))
+	ret(grow, mem, newCap)
+
+	return fn
+}
@@ -1,156 +1,320 @@
 package visitor
 
 import (
+	"go/token"
+
 	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/types"
-	"nano-go/parser"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/ast"
+	"nano-go/check"
 	"nano-go/visitor/name"
 )
 
-func (v *Visitor) visitStatement(ctx *parser.StatementContext) {
-	simpleCtx := ctx.SimpleStmt()
-	if simpleCtx != nil {
-		v.visitSimpleStmt(simpleCtx.(*parser.SimpleStmtContext))
+func (v *Visitor) visitStatement(stmt ast.Stmt) {
+	defer v.recoverStatement()
+
+	// Statements following a return or a branch cannot be reached, they
+	// still need a block.
+	if v.curBlock.Term != nil {
+		v.curBlock = v.curBlock.Parent.NewBlock(name.BlockName() + "-unreachable")
 	}
 
-	if returnCtx := ctx.ReturnStmt(); returnCtx != nil {
-		v.visitReturnStatement(returnCtx.(*parser.ReturnStmtContext))
+	switch s := stmt.(type) {
+	case *ast.ExprStmt, *ast.AssignStmt, *ast.IncDecStmt:
+		v.visitSimpleStmt(s)
+	case *ast.ReturnStmt:
+		v.visitReturnStatement(s)
+	case *ast.BlockStmt:
+		v.visitBlock(s)
+	case *ast.IfStmt:
+		v.visitIfStmt(s)
+	case *ast.SwitchStmt:
+		v.visitSwitchStmt(s)
+	case *ast.ForStmt:
+		v.visitForStmt(s)
+	case *ast.RangeStmt:
+		v.visitRangeStmt(s)
+	case *ast.DeclStmt:
+		v.visitDeclStmt(s)
+	case *ast.LabeledStmt:
+		v.visitLabeledStmt(s)
+	case *ast.BranchStmt:
+		v.visitBranchStmt(s)
+	case *ast.BadStmt:
+		// Reported by the parser.
+	default:
+		v.errorf(stmt, "%s is not implemented yet", statementKind(stmt))
+	}
+}
+
+// statementKind names the kind of stmt for diagnostics.
+func statementKind(stmt ast.Stmt) string {
+	switch stmt.(type) {
+	case *ast.DeclStmt:
+		return "declaration statement"
+	case *ast.SendStmt:
+		return "send statement"
+	case *ast.GoStmt:
+		return "go statement"
+	case *ast.DeferStmt:
+		return "defer statement"
 	}
 
-	if ifCtx := ctx.IfStmt(); ifCtx != nil {
-		v.visitIfStmt(ifCtx.(*parser.IfStmtContext))
+	return "statement"
+}
+
+func (v *Visitor) visitDeclStmt(stmt *ast.DeclStmt) {
+	d, ok := stmt.Decl.(*ast.GenDecl)
+	if !ok {
+		v.fatalf(stmt, "%s is not implemented yet", statementKind(stmt))
 	}
 
-	if forCtx := ctx.ForStmt(); forCtx != nil {
-		v.visitForStmt(forCtx.(*parser.ForStmtContext))
+	switch d.Tok {
+	case token.CONST, token.TYPE:
+		// Constants and types produce no code, their uses are folded
+		// or lowered where they appear.
+	case token.VAR:
+		for _, spec := range d.Specs {
+			v.visitVarSpec(spec.(*ast.ValueSpec))
+		}
+	default:
+		v.fatalf(stmt, "%s is not implemented yet", statementKind(stmt))
 	}
 }
 
-func (v *Visitor) visitIfStmt(ctx *parser.IfStmtContext) {
-	exprI := ctx.Expression()
-	if exprI == nil {
-		panic("unknown condition")
+func (v *Visitor) visitIfStmt(stmt *ast.IfStmt) {
+	// The variables of the init statement are in scope in all branches.
+	v.pushVariablesStack()
+	defer v.popVariablesStack()
+
+	if stmt.Init != nil {
+		v.visitSimpleStmt(stmt.Init)
 	}
 
-	cond := v.visitExpression(exprI.(*parser.ExpressionContext))
+	cond := v.visitExpression(stmt.Cond)
 
 	afterBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-after")
 	trueBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-true")
 	falseBlock := afterBlock
 
-	if ctx.ELSE() != nil {
+	if stmt.Else != nil {
 		falseBlock = v.curBlock.Parent.NewBlock(name.BlockName() + "-false")
 	}
 
 	v.curBlock.NewCondBr(cond, trueBlock, falseBlock)
 	v.curBlock = trueBlock
 
-	v.visitBlock(ctx.Block(0).(*parser.BlockContext))
+	v.visitBlock(stmt.Body)
 
-	if trueBlock.Term == nil {
-		trueBlock.NewBr(afterBlock)
+	// The body may end in a nested statement's block or in a branch.
+	if v.curBlock.Term == nil {
+		v.curBlock.NewBr(afterBlock)
 	}
 
-	if ctx.ELSE() != nil {
+	if stmt.Else != nil {
 		v.curBlock = falseBlock
-		if ctx.IfStmt() != nil {
-			v.visitIfStmt(ctx.IfStmt().(*parser.IfStmtContext))
-			if v.curBlock.Term == nil {
-				v.curBlock.NewBr(afterBlock)
-			}
-		}
-		if ctx.Block(1) != nil {
-			v.visitBlock(ctx.Block(1).(*parser.BlockContext))
+		switch elseStmt := stmt.Else.(type) {
+		case *ast.IfStmt:
+			v.visitIfStmt(elseStmt)
+		case *ast.BlockStmt:
+			v.visitBlock(elseStmt)
 		}
 
-		if falseBlock.Term == nil {
-			falseBlock.NewBr(afterBlock)
+		if v.curBlock.Term == nil {
+			v.curBlock.NewBr(afterBlock)
 		}
 	}
 
 	v.curBlock = afterBlock
 }
 
-func (v *Visitor) visitReturnStatement(ctx *parser.ReturnStmtContext) {
-	if ctx.ExpressionList() == nil {
-		v.curBlock.NewRet(nil)
+func (v *Visitor) visitReturnStatement(stmt *ast.ReturnStmt) {
+	results := v.sig.Results()
+	if results.Len() == 0 {
+		v.retVoid()
 		return
 	}
 
-	expressions := ctx.ExpressionList().(*parser.ExpressionListContext).AllExpression()
-	if len(expressions) == 1 {
-		expr := v.visitExpression(expressions[0].(*parser.ExpressionContext))
-		v.curBlock.NewRet(expr)
+	var values []value.Value
+	if len(stmt.Results) != 0 {
+		values = v.visitValues(stmt.Results, results.Len())
+	}
+
+	// Named results are set by the return statement and then returned.
+	if v.results != nil {
+		returned := make([]value.Value, len(v.results))
+		for i, res := range v.results {
+			if values != nil {
+				v.store(values[i], res.Value)
+			}
+			returned[i] = v.load(res.Type, res.Value)
+		}
+		values = returned
+	}
+
+	if len(values) == 1 {
+		v.curBlock.NewRet(values[0])
 		return
 	}
 
-	panic("multiple return statement is not realised yet")
+	var tuple value.Value = constant.NewUndef(v.curBlock.Parent.Sig.RetType)
+	for i, val := range values {
+		tuple = v.curBlock.NewInsertValue(tuple, val, uint64(i))
+	}
+	v.curBlock.NewRet(tuple)
 }
 
-func (v *Visitor) visitSimpleStmt(ctx *parser.SimpleStmtContext) {
-	shortDeclCtx := ctx.ShortVarDecl()
-	if shortDeclCtx != nil {
-		v.visitShortVarDecl(shortDeclCtx.(*parser.ShortVarDeclContext))
+// visitValues evaluates the n values of the right-hand side of an
+// assignment: n expressions, or a call returning n results.
+func (v *Visitor) visitValues(exprs []ast.Expr, n int) []value.Value {
+	values := make([]value.Value, n)
+
+	if len(exprs) != n {
+		tuple := v.visitExpression(exprs[0])
+		for i := range values {
+			values[i] = v.curBlock.NewExtractValue(tuple, uint64(i))
+		}
+		return values
 	}
 
-	if assigmentCtx := ctx.Assignment(); assigmentCtx != nil {
-		v.visitAssigment(assigmentCtx.(*parser.AssignmentContext))
+	for i, expr := range exprs {
+		values[i] = v.visitExpression(expr)
 	}
+	return values
+}
 
-	if expressionCtx := ctx.ExpressionStmt(); expressionCtx != nil {
-		v.visitExpression(expressionCtx.(*parser.ExpressionStmtContext).Expression().(*parser.ExpressionContext))
+func (v *Visitor) visitSimpleStmt(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case *ast.AssignStmt:
+		if s.Tok == token.DEFINE {
+			v.visitShortVarDecl(s)
+		} else {
+			v.visitAssigment(s)
+		}
+	case *ast.ExprStmt:
+		v.visitExpression(s.X)
+	case *ast.IncDecStmt:
+		v.visitIncDecStmt(s)
+	default:
+		v.fatalf(stmt, "%s is not implemented yet", statementKind(stmt))
 	}
+}
 
-	if incDecCtx := ctx.IncDecStmt(); incDecCtx != nil {
-		v.visitIncDecCtx(incDecCtx.(*parser.IncDecStmtContext))
+// lookupVar finds the variable named by ident.
+func (v *Visitor) lookupVar(ident *ast.Ident) Value {
+	variable, ok := v.getVar(ident.Name)
+	if !ok {
+		v.fatalf(ident, "undefined: %s", ident.Name)
 	}
+
+	return variable
 }
 
-func (v *Visitor) visitIncDecCtx(ctx *parser.IncDecStmtContext) {
-	identifier := ctx.Expression().(*parser.ExpressionContext).GetText()
-	value := v.contextBlockVariables[len(v.contextBlockVariables) - 1][identifier]
+func (v *Visitor) visitIncDecStmt(stmt *ast.IncDecStmt) {
+	ptr := v.address(stmt.X)
+	typ := v.llvmType(v.typeOf(stmt.X))
 
-	one := constant.NewInt(types.I64, 1)
+	load := v.curBlock.NewLoad(typ, ptr)
 
-	if ctx.PLUS_PLUS() != nil {
-		load := v.curBlock.NewLoad(value.Type, value.Value)
-		inced := v.curBlock.NewAdd(load, one)
-		v.curBlock.NewStore(inced, value.Value)
+	if floatType, ok := typ.(*types.FloatType); ok {
+		one := constant.NewFloat(floatType, 1)
+		if stmt.Tok == token.INC {
+			v.curBlock.NewStore(v.curBlock.NewFAdd(load, one), ptr)
+		} else {
+			v.curBlock.NewStore(v.curBlock.NewFSub(load, one), ptr)
+		}
+		return
 	}
 
-	if ctx.MINUS_MINUS() != nil {
-		load := v.curBlock.NewLoad(value.Type, value.Value)
+	one := constant.NewInt(typ.(*types.IntType), 1)
+	if stmt.Tok == token.INC {
+		inced := v.curBlock.NewAdd(load, one)
+		v.curBlock.NewStore(inced, ptr)
+	} else {
 		deced := v.curBlock.NewSub(load, one)
-		v.curBlock.NewStore(deced, value.Value)
+		v.curBlock.NewStore(deced, ptr)
 	}
 }
 
-func (v *Visitor) visitShortVarDecl(ctx *parser.ShortVarDeclContext) {
-	identifier := ctx.IdentifierList().(*parser.IdentifierListContext).IDENTIFIER(0).GetText()
+func (v *Visitor) visitShortVarDecl(stmt *ast.AssignStmt) {
+	values := v.visitValues(stmt.Rhs, len(stmt.Lhs))
 
-	expressionCtx := ctx.ExpressionList().(*parser.ExpressionListContext).Expression(0).(*parser.ExpressionContext)
-	exprValue := v.visitExpression(expressionCtx)
+	for i, lhs := range stmt.Lhs {
+		ident := lhs.(*ast.Ident)
+		if ident.Name == "_" {
+			continue
+		}
+
+		// Variables declared before in the same scope are assigned to.
+		if _, ok := v.Info.Defs[ident]; !ok {
+			v.store(values[i], v.lookupVar(ident).Value)
+			continue
+		}
 
-	alloca := v.curBlock.NewAlloca(exprValue.Type())
-	alloca.SetName(identifier)
-	v.curBlock.NewStore(exprValue, alloca)
+		alloca := v.alloca(values[i].Type(), ident.Name)
+		v.store(values[i], alloca)
 
-	v.setVar(identifier, Value{
-		Value:      alloca,
-		Type:       exprValue.Type(),
-		IsVariable: true,
-	})
+		v.setVar(ident.Name, Value{
+			Value:      alloca,
+			Type:       values[i].Type(),
+			IsVariable: true,
+		})
+	}
 }
 
-func (v *Visitor) visitAssigment(ctx *parser.AssignmentContext) {
-	leftCtx := ctx.ExpressionList(0).(*parser.ExpressionListContext).Expression(0)
-	varName := leftCtx.GetText()
-	variable := v.contextBlockVariables[len(v.contextBlockVariables) - 1][varName]
+func (v *Visitor) visitAssigment(stmt *ast.AssignStmt) {
+	if stmt.Tok != token.ASSIGN {
+		// x op= y is lowered as x = x op y, the address of x is only
+		// computed once. The address of a map element is computed after
+		// y, which may grow the map.
+		typ := v.llvmType(v.typeOf(stmt.Lhs[0]))
+		op := &ast.BinaryExpr{X: stmt.Lhs[0], OpPos: stmt.TokPos, Op: stmt.Op(), Y: stmt.Rhs[0]}
+		if index, ok := ast.Unparen(stmt.Lhs[0]).(*ast.IndexExpr); ok {
+			if t, ok := v.typeOf(index.X).Underlying().(*check.Map); ok {
+				target := v.mapElemTarget(index, t)
+				y := v.visitExpression(stmt.Rhs[0])
+				ptr := target()
+				v.store(v.binaryOp(op, v.load(typ, ptr), y), ptr)
+				return
+			}
+		}
 
-	rightCtx := ctx.ExpressionList(1).(*parser.ExpressionListContext).Expression(0)
-	rightValue := v.visitExpression(rightCtx.(*parser.ExpressionContext))
+		ptr := v.address(stmt.Lhs[0])
+		x := v.load(typ, ptr)
+		y := v.visitExpression(stmt.Rhs[0])
+		v.store(v.binaryOp(op, x, y), ptr)
+		return
+	}
+
+	// The operands of the left-hand side and all the values are computed
+	// before any variable is assigned, so a, b = b, a swaps and i, a[i] = 1, 2
+	// assigns to the element indexed by the old i.
+	targets := make([]func() value.Value, len(stmt.Lhs))
+	for i, lhs := range stmt.Lhs {
+		if !ast.IsBlank(lhs) {
+			targets[i] = v.assignTarget(lhs)
+		}
+	}
+	values := v.visitValues(stmt.Rhs, len(stmt.Lhs))
 
-	v.curBlock.NewStore(rightValue, variable.Value)
-	v.curBlock.NewLoad(rightValue.Type(), variable.Value)
+	for i, target := range targets {
+		if target != nil {
+			v.store(values[i], target())
+		}
+	}
 }
 
+// assignTarget evaluates the operands of the left-hand side expr, checking
+// its indices, and returns a function giving the address it is assigned to.
+func (v *Visitor) assignTarget(expr ast.Expr) func() value.Value {
+	if index, ok := ast.Unparen(expr).(*ast.IndexExpr); ok {
+		if t, ok := v.typeOf(index.X).Underlying().(*check.Map); ok {
+			return v.mapElemTarget(index, t)
+		}
+	}
+
+	ptr := v.address(expr)
+	return func() value.Value { return ptr }
+}
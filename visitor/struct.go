@@ -0,0 +1,104 @@
+package visitor
+
+import (
+	"fmt"
+
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/ast"
+	"nano-go/check"
+	"nano-go/visitor/type"
+)
+
+// loweredStruct is a struct type and the type lowering its values.
+type loweredStruct struct {
+	t   *check.Struct
+	typ *_type.StructType
+}
+
+// structType returns the type lowering the values of typ, whose underlying
+// type is the struct type t. Identical struct types share an LLVM struct,
+// named after the first declared type lowered or else "struct". The LLVM
+// struct is defined before its fields are lowered, they may refer to it
+// through slices and maps.
+func (v *Visitor) structType(typ check.Type, t *check.Struct) *_type.StructType {
+	for _, s := range v.structTypes {
+		if check.Identical(s.t, t) {
+			return s.typ
+		}
+	}
+
+	name := "struct"
+	if named, ok := typ.(*check.Named); ok {
+		name = pkgSymbol(named.Obj().Name())
+	}
+	if n := v.typeNames[name]; n > 0 {
+		v.typeNames[name]++
+		name = fmt.Sprintf("%s.%d", name, n)
+	} else {
+		v.typeNames[name]++
+	}
+
+	s := &_type.StructType{
+		TypeName: name,
+		Type:     v.Module.NewTypeDef(name, &types.StructType{}).(*types.StructType),
+	}
+	v.structTypes = append(v.structTypes, loweredStruct{t, s})
+
+	for i := 0; i < t.NumFields(); i++ {
+		field := v.goType(t.Field(i).Type())
+		s.Fields = append(s.Fields, field)
+		s.Type.Fields = append(s.Type.Fields, memoryType(field.LLVM()))
+	}
+	return s
+}
+
+// fieldIndex returns the index of the field obj of the struct type t.
+func fieldIndex(t *check.Struct, obj check.Object) int64 {
+	for i := 0; i < t.NumFields(); i++ {
+		if t.Field(i) == obj {
+			return int64(i)
+		}
+	}
+	panic(fmt.Sprintf("%s is not a field of %s", obj.Name(), t))
+}
+
+// visitStructLit lowers a struct literal. The fields start as zeros in a
+// temporary variable, the ones of the literal are stored in order.
+func (v *Visitor) visitStructLit(lit *ast.CompositeLit, t *check.Struct) value.Value {
+	typ := v.goType(v.typeOf(lit)).(*_type.StructType)
+	tmp := v.alloca(typ.Type, "")
+	typ.Zero(v.curBlock, tmp)
+
+	for i, elt := range lit.Elts {
+		index := int64(i)
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			index = fieldIndex(t, v.Info.Uses[kv.Key.(*ast.Ident)])
+			elt = kv.Value
+		}
+		v.store(v.visitExpression(elt), field(v.curBlock, typ.Type, tmp, index))
+	}
+
+	return v.load(typ.Type, tmp)
+}
+
+// visitSelectorExpr lowers the selection of a field. The field of a
+// variable is loaded from its address, the one of a value is extracted.
+func (v *Visitor) visitSelectorExpr(expr *ast.SelectorExpr) value.Value {
+	typ := v.llvmType(v.typeOf(expr))
+	if v.Info.Types[expr.X].Addressable() {
+		return v.load(typ, v.fieldAddr(expr))
+	}
+
+	t := v.typeOf(expr.X).Underlying().(*check.Struct)
+	val := v.curBlock.NewExtractValue(v.visitExpression(expr.X), uint64(fieldIndex(t, v.Info.Uses[expr.Sel])))
+	return v.fromMemory(typ, val)
+}
+
+// fieldAddr returns the address of the field of a variable expr selects.
+func (v *Visitor) fieldAddr(expr *ast.SelectorExpr) value.Value {
+	t := v.typeOf(expr.X).Underlying().(*check.Struct)
+	typ := v.goType(v.typeOf(expr.X)).(*_type.StructType)
+	return field(v.curBlock, typ.Type, v.address(expr.X), fieldIndex(t, v.Info.Uses[expr.Sel]))
+}
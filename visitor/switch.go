@@ -0,0 +1,132 @@
+package visitor
+
+import (
+	goconstant "go/constant"
+	"go/token"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/ast"
+	"nano-go/check"
+	"nano-go/visitor/name"
+)
+
+// visitSwitchStmt lowers an expression switch. The cases become a switch
+// instruction when the tag is an integer and all case values are constant,
+// otherwise the tag is compared with the case values in order.
+func (v *Visitor) visitSwitchStmt(stmt *ast.SwitchStmt) {
+	v.pushVariablesStack()
+	defer v.popVariablesStack()
+
+	if stmt.Init != nil {
+		v.visitSimpleStmt(stmt.Init)
+	}
+
+	var tag value.Value
+	if stmt.Tag != nil {
+		tag = v.visitExpression(stmt.Tag)
+	}
+
+	clauses := make([]*ast.CaseClause, len(stmt.Body.List))
+	bodies := make([]*ir.Block, len(stmt.Body.List))
+	for i, clause := range stmt.Body.List {
+		clauses[i] = clause.(*ast.CaseClause)
+		bodies[i] = v.curBlock.Parent.NewBlock(name.BlockName() + "-case")
+	}
+	afterBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-after-switch")
+
+	// The default clause is taken when no case matches, wherever it is.
+	defaultBlock := afterBlock
+	for i, clause := range clauses {
+		if clause.List == nil {
+			defaultBlock = bodies[i]
+		}
+	}
+
+	if tag != nil && v.integerCases(stmt.Tag, clauses) {
+		var cases []*ir.Case
+		for i, clause := range clauses {
+			for _, expr := range clause.List {
+				val := v.visitExpression(expr).(constant.Constant)
+				cases = append(cases, ir.NewCase(val, bodies[i]))
+			}
+		}
+		v.curBlock.NewSwitch(tag, defaultBlock, cases...)
+	} else {
+		for i, clause := range clauses {
+			for _, expr := range clause.List {
+				cond := v.caseCondition(stmt.Tag, tag, expr)
+				nextBlock := v.curBlock.Parent.NewBlock(name.BlockName() + "-next-case")
+				v.curBlock.NewCondBr(cond, bodies[i], nextBlock)
+				v.curBlock = nextBlock
+			}
+		}
+		v.curBlock.NewBr(defaultBlock)
+	}
+
+	v.pushBranchTarget(afterBlock, nil)
+	defer v.popBranchTarget()
+
+	for i, clause := range clauses {
+		v.curBlock = bodies[i]
+		v.pushVariablesStack()
+
+		next := afterBlock
+		for _, s := range clause.Body {
+			// The checker only allows fallthrough as the final statement
+			// of a case that is not the last one.
+			if branch, ok := s.(*ast.BranchStmt); ok && branch.Tok == token.FALLTHROUGH {
+				next = bodies[i+1]
+				continue
+			}
+			v.visitStatement(s)
+		}
+
+		if v.curBlock.Term == nil {
+			v.curBlock.NewBr(next)
+		}
+		v.popVariablesStack()
+	}
+
+	v.curBlock = afterBlock
+}
+
+// integerCases reports whether the switch on tag can use a switch
+// instruction: the tag is an integer and every case value is a constant.
+func (v *Visitor) integerCases(tag ast.Expr, clauses []*ast.CaseClause) bool {
+	t, ok := check.Default(v.typeOf(tag)).Underlying().(*check.Basic)
+	if !ok || t.Info()&check.IsInteger == 0 {
+		return false
+	}
+
+	for _, clause := range clauses {
+		for _, expr := range clause.List {
+			if v.Info.Types[expr].Value == nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// caseCondition compares the case value expr with the switch tag, whose
+// value is tag. A switch without a tag takes the case values as conditions.
+func (v *Visitor) caseCondition(tagExpr ast.Expr, tag value.Value, expr ast.Expr) value.Value {
+	if tagExpr == nil {
+		return v.visitExpression(expr)
+	}
+
+	// Constants that cannot be compared at run time, like strings, are
+	// compared here.
+	x, y := v.Info.Types[tagExpr].Value, v.Info.Types[expr].Value
+	if x != nil && y != nil {
+		return constant.NewBool(goconstant.Compare(x, token.EQL, y))
+	}
+
+	// The case is lowered like tag == expr, so every type comparable with
+	// == is.
+	eql := &ast.BinaryExpr{X: tagExpr, OpPos: expr.Pos(), Op: token.EQL, Y: expr}
+	return v.binaryOp(eql, tag, v.visitExpression(expr))
+}
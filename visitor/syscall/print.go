@@ -9,6 +9,11 @@ import (
 )
 
 func Print(block *ir.Block, value value.Value, goos string) {
+	Write(block, 1, value, goos)
+}
+
+// Write writes the string value to the file descriptor fd.
+func Write(block *ir.Block, fd int64, value value.Value, goos string) {
 	asmFunc := ir.NewInlineAsm(types.NewPointer(types.NewFunc(types.I64)), "syscall", "=r,{rax},{rdi},{rsi},{rdx}")
 	asmFunc.SideEffect = true
 
@@ -17,8 +22,19 @@ func Print(block *ir.Block, value value.Value, goos string) {
 
 	block.NewCall(asmFunc,
 		constant.NewInt(types.I64, Convert(WRITE, goos)), // rax
-		constant.NewInt(types.I64, 1),                    // rdi, stdout
+		constant.NewInt(types.I64, fd),                   // rdi
 		strPtr,                                           // rsi
 		strLen,                                           // rdx
 	)
 }
+
+// Exit ends the process with the status code.
+func Exit(block *ir.Block, code value.Value, goos string) {
+	asmFunc := ir.NewInlineAsm(types.NewPointer(types.NewFunc(types.I64)), "syscall", "=r,{rax},{rdi}")
+	asmFunc.SideEffect = true
+
+	block.NewCall(asmFunc,
+		constant.NewInt(types.I64, Convert(EXIT, goos)), // rax
+		code, // rdi
+	)
+}
@@ -1,6 +1,8 @@
 package _type
 
 import (
+	"fmt"
+
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
 	"github.com/llir/llvm/ir/types"
@@ -58,4 +60,236 @@ func (s StringType) Zero(block *ir.Block, alloca value.Value) {
 	backingDataPtr := block.NewGetElementPtr(pointer.ElemType(alloca), alloca, constant.NewInt(types.I32, 0), constant.NewInt(types.I32, 1))
 	block.NewStore(constant.NewInt(types.I64, 0), lenPtr)
 	block.NewStore(strings.Toi8Ptr(block, EmptyStringConstant), backingDataPtr)
-}
\ No newline at end of file
+}
+
+type IntType struct {
+	backingType
+	Type     *types.IntType
+	TypeName string
+	Unsigned bool
+}
+
+var (
+	Int     = &IntType{Type: types.I64, TypeName: "int"}
+	Int8    = &IntType{Type: types.I8, TypeName: "int8"}
+	Int16   = &IntType{Type: types.I16, TypeName: "int16"}
+	Int32   = &IntType{Type: types.I32, TypeName: "int32"}
+	Int64   = &IntType{Type: types.I64, TypeName: "int64"}
+	Uint    = &IntType{Type: types.I64, TypeName: "uint", Unsigned: true}
+	Uint8   = &IntType{Type: types.I8, TypeName: "uint8", Unsigned: true}
+	Uint16  = &IntType{Type: types.I16, TypeName: "uint16", Unsigned: true}
+	Uint32  = &IntType{Type: types.I32, TypeName: "uint32", Unsigned: true}
+	Uint64  = &IntType{Type: types.I64, TypeName: "uint64", Unsigned: true}
+	Uintptr = &IntType{Type: types.I64, TypeName: "uintptr", Unsigned: true}
+)
+
+func (t IntType) LLVM() types.Type {
+	return t.Type
+}
+
+func (t IntType) Name() string {
+	return t.TypeName
+}
+
+func (t IntType) Size() int64 {
+	return int64(t.Type.BitSize / 8)
+}
+
+func (t IntType) Zero(block *ir.Block, alloca value.Value) {
+	block.NewStore(constant.NewInt(t.Type, 0), alloca)
+}
+
+type BoolType struct {
+	backingType
+}
+
+var Bool = &BoolType{}
+
+func (BoolType) LLVM() types.Type {
+	return types.I1
+}
+
+func (BoolType) Name() string {
+	return "bool"
+}
+
+func (BoolType) Size() int64 {
+	return 1
+}
+
+func (BoolType) Zero(block *ir.Block, alloca value.Value) {
+	// Booleans take a byte in memory.
+	block.NewStore(constant.NewInt(types.I8, 0), alloca)
+}
+
+type FloatType struct {
+	backingType
+	Type     *types.FloatType
+	TypeName string
+}
+
+var (
+	Float32 = &FloatType{Type: types.Float, TypeName: "float32"}
+	Float64 = &FloatType{Type: types.Double, TypeName: "float64"}
+)
+
+func (t FloatType) LLVM() types.Type {
+	return t.Type
+}
+
+func (t FloatType) Name() string {
+	return t.TypeName
+}
+
+func (t FloatType) Size() int64 {
+	if t.Type.Kind == types.FloatKindFloat {
+		return 4
+	}
+	return 8
+}
+
+func (t FloatType) Zero(block *ir.Block, alloca value.Value) {
+	block.NewStore(constant.NewFloat(t.Type, 0), alloca)
+}
+
+// SliceType is lowered to a struct of the pointer to the backing array, the
+// length and the capacity.
+type SliceType struct {
+	backingType
+	Elem Type
+}
+
+func (t SliceType) LLVM() types.Type {
+	return types.NewStruct(
+		types.NewPointer(elemType(t.Elem)), // Backing array
+		types.I64,                          // Length
+		types.I64,                          // Capacity
+	)
+}
+
+func (t SliceType) Name() string {
+	return "[]" + t.Elem.Name()
+}
+
+func (SliceType) Size() int64 {
+	return 24
+}
+
+func (t SliceType) Zero(block *ir.Block, alloca value.Value) {
+	block.NewStore(constant.NewZeroInitializer(t.LLVM()), alloca)
+}
+
+// ArrayType is lowered to an LLVM array. The elements have the type they
+// have in memory, in registers too.
+type ArrayType struct {
+	backingType
+	Len  int64
+	Elem Type
+}
+
+func (t ArrayType) LLVM() types.Type {
+	return types.NewArray(uint64(t.Len), elemType(t.Elem))
+}
+
+func (t ArrayType) Name() string {
+	return fmt.Sprintf("[%d]%s", t.Len, t.Elem.Name())
+}
+
+func (t ArrayType) Size() int64 {
+	return t.Len * t.Elem.Size()
+}
+
+func (t ArrayType) Zero(block *ir.Block, alloca value.Value) {
+	block.NewStore(constant.NewZeroInitializer(t.LLVM()), alloca)
+}
+
+// MapType is lowered to a pointer to the hash table of the runtime, nil for
+// the nil map.
+type MapType struct {
+	backingType
+	Key  Type
+	Elem Type
+}
+
+func (t MapType) LLVM() types.Type {
+	return types.NewPointer(types.I8)
+}
+
+func (t MapType) Name() string {
+	return "map[" + t.Key.Name() + "]" + t.Elem.Name()
+}
+
+func (MapType) Size() int64 {
+	return 8
+}
+
+func (t MapType) Zero(block *ir.Block, alloca value.Value) {
+	block.NewStore(constant.NewNull(types.NewPointer(types.I8)), alloca)
+}
+
+// StructType is lowered to an identified LLVM struct. The fields have the
+// type they have in memory, in registers too.
+type StructType struct {
+	backingType
+	TypeName string
+	Fields   []Type
+	Type     *types.StructType
+}
+
+func (t StructType) LLVM() types.Type {
+	return t.Type
+}
+
+func (t StructType) Name() string {
+	return t.TypeName
+}
+
+// Size is the size of the LLVM struct, the fields are aligned like their
+// types and the size is a multiple of the alignment of the struct.
+func (t StructType) Size() int64 {
+	var size int64
+	for _, field := range t.Fields {
+		size = alignTo(size, alignOf(field)) + field.Size()
+	}
+	return alignTo(size, alignOf(&t))
+}
+
+func (t StructType) Zero(block *ir.Block, alloca value.Value) {
+	for i, field := range t.Fields {
+		ptr := block.NewGetElementPtr(t.Type, alloca, constant.NewInt(types.I32, 0), constant.NewInt(types.I32, int64(i)))
+		field.Zero(block, ptr)
+	}
+}
+
+// alignOf returns the alignment of the values of type t in memory.
+func alignOf(t Type) int64 {
+	switch t := t.(type) {
+	case *ArrayType:
+		return alignOf(t.Elem)
+	case *StructType:
+		var align int64 = 1
+		for _, field := range t.Fields {
+			if a := alignOf(field); a > align {
+				align = a
+			}
+		}
+		return align
+	case *StringType, *SliceType, *MapType:
+		return 8
+	}
+	return t.Size()
+}
+
+// alignTo rounds the offset n up to a multiple of align.
+func alignTo(n, align int64) int64 {
+	return (n + align - 1) / align * align
+}
+
+// elemType returns the LLVM type of the elements of type elem in an array.
+func elemType(elem Type) types.Type {
+	if _, ok := elem.(*BoolType); ok {
+		// Booleans take a byte in memory.
+		return types.I8
+	}
+	return elem.LLVM()
+}
@@ -0,0 +1,74 @@
+package visitor
+
+import (
+	"fmt"
+
+	"github.com/llir/llvm/ir/types"
+
+	"nano-go/ast"
+	"nano-go/check"
+	"nano-go/visitor/type"
+)
+
+func (v *Visitor) typeOf(expr ast.Expr) check.Type {
+	return v.Info.TypeOf(expr)
+}
+
+// basicTypes maps the basic types to the types lowering their values.
+var basicTypes = map[check.BasicKind]_type.Type{
+	check.Bool:    _type.Bool,
+	check.Int:     _type.Int,
+	check.Int8:    _type.Int8,
+	check.Int16:   _type.Int16,
+	check.Int32:   _type.Int32,
+	check.Int64:   _type.Int64,
+	check.Uint:    _type.Uint,
+	check.Uint8:   _type.Uint8,
+	check.Uint16:  _type.Uint16,
+	check.Uint32:  _type.Uint32,
+	check.Uint64:  _type.Uint64,
+	check.Uintptr: _type.Uintptr,
+	check.Float32: _type.Float32,
+	check.Float64: _type.Float64,
+	check.String:  _type.String,
+}
+
+// goType returns the type that lowers the values of t. Untyped values are
+// lowered with their default type.
+func (v *Visitor) goType(typ check.Type) _type.Type {
+	switch t := check.Default(typ).Underlying().(type) {
+	case *check.Basic:
+		if typ, ok := basicTypes[t.Kind()]; ok {
+			return typ
+		}
+	case *check.Array:
+		return &_type.ArrayType{Len: t.Len(), Elem: v.goType(t.Elem())}
+	case *check.Slice:
+		return &_type.SliceType{Elem: v.goType(t.Elem())}
+	case *check.Map:
+		return &_type.MapType{Key: v.goType(t.Key()), Elem: v.goType(t.Elem())}
+	case *check.Struct:
+		return v.structType(typ, t)
+	}
+
+	panic(fmt.Sprintf("type %s cannot be lowered", typ))
+}
+
+// isUnsigned reports whether the values of t are lowered to unsigned
+// integers.
+func (v *Visitor) isUnsigned(t check.Type) bool {
+	typ, ok := v.goType(t).(*_type.IntType)
+	return ok && typ.Unsigned
+}
+
+// isFloat reports whether the values of t are lowered to floating-point
+// numbers.
+func (v *Visitor) isFloat(t check.Type) bool {
+	_, ok := v.goType(t).(*_type.FloatType)
+	return ok
+}
+
+// llvmType returns the LLVM type of the values of t.
+func (v *Visitor) llvmType(t check.Type) types.Type {
+	return v.goType(t).LLVM()
+}
@@ -0,0 +1,128 @@
+package visitor
+
+import (
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/visitor/name"
+)
+
+// runeError is the rune an invalid UTF-8 sequence decodes to.
+const runeError = 0xFFFD
+
+// decodeRuneFunc returns the runtime function decoding the first rune of
+// the n bytes at p. It returns the rune and its width like
+// utf8.DecodeRune, invalid sequences are runeError of width 1. It is
+// generated by the first range loop over a string.
+func (v *Visitor) decodeRuneFunc() *ir.Func {
+	if v.decodeRuneFn != nil {
+		return v.decodeRuneFn
+	}
+
+	p := ir.NewParam("p", types.NewPointer(types.I8))
+	n := ir.NewParam("n", types.I64)
+	result := types.NewStruct(types.I32, types.I64)
+	v.decodeRuneFn = v.Module.NewFunc("runtime.decodeRune", result, p, n)
+	fn := v.decodeRuneFn
+
+	i32 := func(x int64) constant.Constant { return constant.NewInt(types.I32, x) }
+	i64 := func(x int64) constant.Constant { return constant.NewInt(types.I64, x) }
+
+	ret := func(block *ir.Block, r, width value.Value) {
+		var res value.Value = constant.NewUndef(result)
+		res = block.NewInsertValue(res, r, 0)
+		res = block.NewInsertValue(res, width, 1)
+		block.NewRet(res)
+	}
+
+	// load returns the i-th byte as an i32.
+	load := func(block *ir.Block, i int64) value.Value {
+		ptr := block.NewGetElementPtr(types.I8, p, i64(i))
+		return block.NewZExt(block.NewLoad(types.I8, ptr), types.I32)
+	}
+
+	// isCont reports whether b is a continuation byte 10xxxxxx.
+	isCont := func(block *ir.Block, b value.Value) value.Value {
+		return block.NewICmp(enum.IPredEQ, block.NewAnd(b, i32(0xC0)), i32(0x80))
+	}
+
+	// bits returns the payload of b, masked with mask, shifted left.
+	bits := func(block *ir.Block, b value.Value, mask, shift int64) value.Value {
+		return block.NewShl(block.NewAnd(b, i32(mask)), i32(shift))
+	}
+
+	newBlock := func(suffix string) *ir.Block {
+		return fn.NewBlock(name.BlockName() + suffix)
+	}
+
+	entry := newBlock("")
+	invalid := newBlock("-invalid")
+	ret(invalid, i32(runeError), i64(1))
+
+	// ASCII
+	b0 := load(entry, 0)
+	ascii := newBlock("-ascii")
+	multi := newBlock("-multi")
+	entry.NewCondBr(entry.NewICmp(enum.IPredULT, b0, i32(0x80)), ascii, multi)
+	ret(ascii, b0, i64(1))
+
+	// The sequences of 2, 3 and 4 bytes start with 0xC2 to 0xF4.
+	lead := newBlock("-lead")
+	multi.NewCondBr(multi.NewAnd(
+		multi.NewICmp(enum.IPredUGE, b0, i32(0xC2)),
+		multi.NewICmp(enum.IPredULE, b0, i32(0xF4)),
+	), lead, invalid)
+
+	// continuation reads the i-th byte, it goes on in the returned block
+	// when there is one.
+	continuation := func(block *ir.Block, i int64) (value.Value, *ir.Block) {
+		has := newBlock("-has")
+		block.NewCondBr(block.NewICmp(enum.IPredSGT, n, i64(i)), has, invalid)
+
+		b := load(has, i)
+		next := newBlock("-cont")
+		has.NewCondBr(isCont(has, b), next, invalid)
+		return b, next
+	}
+
+	b1, block := continuation(lead, 1)
+	two := newBlock("-two")
+	more := newBlock("-more")
+	block.NewCondBr(block.NewICmp(enum.IPredULE, b0, i32(0xDF)), two, more)
+	ret(two, two.NewOr(bits(two, b0, 0x1F, 6), bits(two, b1, 0x3F, 0)), i64(2))
+
+	// Three bytes encode 0x800 to 0xFFFF without the surrogate halves.
+	b2, block := continuation(more, 2)
+	three := newBlock("-three")
+	four := newBlock("-four")
+	block.NewCondBr(block.NewICmp(enum.IPredULE, b0, i32(0xEF)), three, four)
+
+	r := three.NewOr(bits(three, b0, 0x0F, 12), three.NewOr(bits(three, b1, 0x3F, 6), bits(three, b2, 0x3F, 0)))
+	ok3 := newBlock("-ok")
+	three.NewCondBr(three.NewOr(
+		three.NewICmp(enum.IPredULT, r, i32(0x800)),
+		three.NewAnd(
+			three.NewICmp(enum.IPredUGE, r, i32(0xD800)),
+			three.NewICmp(enum.IPredULE, r, i32(0xDFFF)),
+		),
+	), invalid, ok3)
+	ret(ok3, r, i64(3))
+
+	// Four bytes encode 0x10000 to 0x10FFFF.
+	b3, block := continuation(four, 3)
+	r = block.NewOr(
+		block.NewOr(bits(block, b0, 0x07, 18), bits(block, b1, 0x3F, 12)),
+		block.NewOr(bits(block, b2, 0x3F, 6), bits(block, b3, 0x3F, 0)),
+	)
+	ok4 := newBlock("-ok")
+	block.NewCondBr(block.NewOr(
+		block.NewICmp(enum.IPredULT, r, i32(0x10000)),
+		block.NewICmp(enum.IPredUGT, r, i32(0x10FFFF)),
+	), invalid, ok4)
+	ret(ok4, r, i64(4))
+
+	return fn
+}
@@ -0,0 +1,115 @@
+package visitor
+
+import (
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+
+	"nano-go/ast"
+	"nano-go/check"
+)
+
+// visitPkgVarDecl declares the package-level variables of decl as globals.
+// A variable initialized by a constant of a type other than string gets it
+// as the initializer of its global, the others are set up by initPkgVars.
+func (v *Visitor) visitPkgVarDecl(decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		s := spec.(*ast.ValueSpec)
+		for i, ident := range s.Names {
+			if ident.Name == "_" {
+				continue
+			}
+
+			obj := v.Info.Defs[ident].(*check.Var)
+			typ := v.llvmType(obj.Type())
+
+			global := v.Module.NewGlobalDef(pkgSymbol(ident.Name), constant.NewZeroInitializer(memoryType(typ)))
+			if len(s.Values) == len(s.Names) {
+				if tv := v.Info.Types[s.Values[i]]; tv.Value != nil && !isStringType(obj.Type()) {
+					global.Init = v.constValue(tv.Value, tv.Type).(constant.Constant)
+					if b, ok := global.Init.(*constant.Int); ok && typ == types.I1 {
+						global.Init = constant.NewInt(types.I8, b.X.Int64())
+					}
+				}
+			}
+
+			v.pkgVars[ident.Name] = Value{
+				Type:       typ,
+				Value:      global,
+				IsVariable: true,
+			}
+			v.pkgVarList = append(v.pkgVarList, obj)
+		}
+	}
+}
+
+// initPkgVars emits the code that gives the package-level variables their
+// zero values and runs their initializers, it runs first in main.
+func (v *Visitor) initPkgVars() {
+	static := make(map[check.Object]bool)
+	for _, init := range v.Info.InitOrder {
+		if len(init.Lhs) == 1 && v.Info.Types[init.Rhs].Value != nil && !isStringType(init.Lhs[0].Type()) {
+			static[init.Lhs[0]] = true
+		}
+	}
+
+	for _, obj := range v.pkgVarList {
+		if !static[obj] {
+			global := v.pkgVars[obj.Name()]
+			v.goType(obj.Type()).Zero(v.curBlock, global.Value)
+		}
+	}
+
+	for _, init := range v.Info.InitOrder {
+		if !static[init.Lhs[0]] {
+			v.initPkgVar(init)
+		}
+	}
+}
+
+func (v *Visitor) initPkgVar(init *check.Initializer) {
+	defer v.recoverStatement()
+
+	values := v.visitValues([]ast.Expr{init.Rhs}, len(init.Lhs))
+	for i, obj := range init.Lhs {
+		if name := obj.Name(); name != "_" {
+			v.store(values[i], v.pkgVars[name].Value)
+		}
+	}
+}
+
+// visitVarSpec declares the local variables of s. Variables without a
+// value get the zero value of their type.
+func (v *Visitor) visitVarSpec(s *ast.ValueSpec) {
+	// The values are computed before the variables are in scope.
+	var values []value.Value
+	if len(s.Values) != 0 {
+		values = v.visitValues(s.Values, len(s.Names))
+	}
+
+	for i, ident := range s.Names {
+		if ident.Name == "_" {
+			continue
+		}
+
+		typ := v.goType(v.Info.Defs[ident].Type())
+
+		alloca := v.alloca(typ.LLVM(), ident.Name)
+		if values != nil {
+			v.store(values[i], alloca)
+		} else {
+			typ.Zero(v.curBlock, alloca)
+		}
+
+		v.setVar(ident.Name, Value{
+			Value:      alloca,
+			Type:       typ.LLVM(),
+			IsVariable: true,
+		})
+	}
+}
+
+func isStringType(t check.Type) bool {
+	basic, ok := check.Default(t).Underlying().(*check.Basic)
+	return ok && basic.Kind() == check.String
+}
@@ -1,21 +1,24 @@
 package visitor
 
 import (
+	"go/token"
+
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/types"
 	"github.com/llir/llvm/ir/value"
 
-	"nano-go/parser"
+	"nano-go/ast"
+	"nano-go/check"
+	"nano-go/diag"
 	"nano-go/visitor/strings"
 	"nano-go/visitor/type"
 )
 
-
 type Value struct {
 	Type  types.Type
 	Value value.Value
 
-	IsVariable bool
+	IsVariable  bool
 	MultiValues []Value
 }
 
@@ -23,15 +26,87 @@ type Visitor struct {
 	Module   *ir.Module
 	curBlock *ir.Block
 
-	contextBlockVariables []map[string]Value
-	pkgVars				  map[string]Value
+	// GOOS is the target operating system, used to pick syscall numbers.
+	GOOS string
 
+	// Info holds the types and objects of the checked file.
+	Info *check.Info
+
+	// Diags collects the errors found while visiting. It is allocated by
+	// VisitFile when nil.
+	Diags *diag.List
+
+	// contextBlockVariables are the scopes of the current function,
+	// innermost last.
+	contextBlockVariables []map[string]Value
+	pkgVars               map[string]Value
+	pkgVarList            []*check.Var
+	// localNames counts the variables of the current function by name,
+	// shadowing variables get numbered names.
+	localNames map[string]int
+
+	// sig is the signature of the function being visited, results are its
+	// named results.
+	sig     *check.Signature
+	results []Value
+
+	// branches are the loops and switches enclosing the statement being
+	// visited, innermost last. label is the label of the next one.
+	branches []branchTarget
+	label    string
+	// labels are the blocks of the labeled statements of the function.
+	labels map[string]*ir.Block
+
+	// printfFn is the printf of C lowering Printf.
+	printfFn *ir.Func
+	// panicFn reports runtime errors and panicfFn formats the ones
+	// reporting values, they are nil until the first one.
+	panicFn  *ir.Func
+	panicfFn *ir.Func
+	// snprintfFn formats the runtime errors reporting values, it is
+	// declared by the first one.
+	snprintfFn *ir.Func
+	// callocFn allocates heap memory, it is declared by the first
+	// allocation.
+	callocFn *ir.Func
+	// decodeRuneFn decodes UTF-8, it is nil until the first range loop
+	// over a string.
+	decodeRuneFn *ir.Func
+	// growSliceFn makes room for appended elements and memmoveFn copies
+	// them, they are nil until the first append or copy.
+	growSliceFn *ir.Func
+	memmoveFn   *ir.Func
+	// mapFns are the functions of the map runtime and the hash and
+	// equality functions of the keys by name, mapFunc generates them when
+	// they are first used. hmapType and hiterType are the types of the
+	// tables and of the range loop states, nil until the first map.
+	mapFns    map[string]*ir.Func
+	hmapType  *types.StructType
+	hiterType *types.StructType
+	// structTypes are the struct types lowered so far, identical types
+	// share one. typeNames counts the struct types by name, the ones
+	// declared with the same name get numbered names.
+	structTypes []loweredStruct
+	typeNames   map[string]int
 }
 
 func (v *Visitor) setVar(name string, val Value) {
 	v.contextBlockVariables[len(v.contextBlockVariables)-1][name] = val
 }
 
+// getVar finds the variable name in the innermost scope of the current
+// function that declares it, or else the package-level variable.
+func (v *Visitor) getVar(name string) (Value, bool) {
+	for i := len(v.contextBlockVariables) - 1; i >= 0; i-- {
+		if val, ok := v.contextBlockVariables[i][name]; ok {
+			return val, true
+		}
+	}
+
+	val, ok := v.pkgVars[name]
+	return val, ok && val.IsVariable
+}
+
 func (v *Visitor) pushVariablesStack() {
 	v.contextBlockVariables = append(v.contextBlockVariables, make(map[string]Value))
 }
@@ -40,10 +115,26 @@ func (v *Visitor) popVariablesStack() {
 	v.contextBlockVariables = v.contextBlockVariables[0 : len(v.contextBlockVariables)-1]
 }
 
-func (v *Visitor) VisitSourceFile(ctx *parser.SourceFileContext) {
+// VisitFile lowers a file that passed check.Check into v.Module.
+func (v *Visitor) VisitFile(file *ast.File) {
+	if v.Diags == nil {
+		v.Diags = &diag.List{}
+	}
 	v.pkgVars = make(map[string]Value)
 	v.contextBlockVariables = make([]map[string]Value, 0)
 	v.Module = ir.NewModule()
+	v.panicFn = nil
+	v.panicfFn = nil
+	v.callocFn = nil
+	v.decodeRuneFn = nil
+	v.snprintfFn = nil
+	v.growSliceFn = nil
+	v.memmoveFn = nil
+	v.mapFns = make(map[string]*ir.Func)
+	v.hmapType = nil
+	v.hiterType = nil
+	v.structTypes = nil
+	v.typeNames = make(map[string]int)
 
 	_type.ModuleStringType = v.Module.NewTypeDef("string", strings.String())
 
@@ -51,48 +142,66 @@ func (v *Visitor) VisitSourceFile(ctx *parser.SourceFileContext) {
 	_type.EmptyStringConstant = v.Module.NewGlobalDef(strings.NextStringName(), strings.Constant(""))
 	_type.EmptyStringConstant.Immutable = true
 
-	setExternal := func(internalName string, fn *ir.Func, variadic bool) Value {
-		fn.Sig.Variadic = variadic
-		val := Value{
-			Type: fn.Type(),
-			Value: fn,
+	v.printfFn = v.Module.NewFunc("printf", types.I32, ir.NewParam("_", types.NewPointer(types.I8)))
+	v.printfFn.Sig.Variadic = true
+
+	var funcDecls []*ast.FuncDecl
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			funcDecls = append(funcDecls, d)
+		case *ast.GenDecl:
+			if d.Tok == token.VAR {
+				v.visitPkgVarDecl(d)
+			}
 		}
-		v.pkgVars[internalName] = val
-		return val
 	}
 
-	setExternal("Printf", v.Module.NewFunc("printf",
-		types.I32,
-		ir.NewParam("_", types.NewPointer(types.I8)),
-	), true)
-
-	v.visitFunctionDecls(ctx.AllFunctionDecl())
+	v.visitFunctionDecls(funcDecls)
 }
 
-func (v *Visitor) visitFunctionDecls(ctxs []parser.IFunctionDeclContext) {
-	for _, ctx := range ctxs {
-		fCtx := ctx.(*parser.FunctionDeclContext)
-		if fCtx.IDENTIFIER().GetText() != "main" {
-			v.visitFunc(fCtx)
+func (v *Visitor) visitFunctionDecls(decls []*ast.FuncDecl) {
+	// Functions may be called before they are declared.
+	for _, decl := range decls {
+		if decl.Name.Name != "main" {
+			v.declareFunc(decl)
+		}
+	}
+
+	for _, decl := range decls {
+		if decl.Name.Name != "main" {
+			v.visitFunctionDecl(decl, v.visitFunc)
 		}
 	}
 
-	for _, ctx := range ctxs {
-		fCtx := ctx.(*parser.FunctionDeclContext)
-		if fCtx.IDENTIFIER().GetText() == "main" {
-			v.visitMain(fCtx)
+	for _, decl := range decls {
+		if decl.Name.Name == "main" {
+			v.visitFunctionDecl(decl, v.visitMain)
 		}
 	}
 }
 
-func (v *Visitor) visitBlock(ctx *parser.BlockContext) {
-	list := ctx.StatementList()
-	children := list.GetChildren()
+func (v *Visitor) visitFunctionDecl(decl *ast.FuncDecl, visit func(*ast.FuncDecl)) {
+	defer v.recoverStatement()
 
-	for _, sCtx := range children {
-		switch sCtx.(type) {
-		case *parser.StatementContext:
-			v.visitStatement(sCtx.(*parser.StatementContext))
-		}
+	if decl.Body == nil {
+		v.fatalf(decl.Name, "missing function body")
+	}
+
+	v.contextBlockVariables = nil
+	v.localNames = make(map[string]int)
+	v.branches = nil
+	v.label = ""
+	v.labels = make(map[string]*ir.Block)
+
+	visit(decl)
+}
+
+func (v *Visitor) visitBlock(block *ast.BlockStmt) {
+	v.pushVariablesStack()
+	defer v.popVariablesStack()
+
+	for _, stmt := range block.List {
+		v.visitStatement(stmt)
 	}
 }